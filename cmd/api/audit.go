@@ -0,0 +1,67 @@
+// Filename: cmd/api/audit.go
+package main
+
+import (
+	"net/http"
+
+	"github.com/Duane-Arzu/test2/internal/data"
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// auditActor identifies the user and request behind a change, for the audit log
+func (a *applicationDependencies) auditActor(r *http.Request) data.AuditActor {
+	return data.AuditActor{
+		UserID:    a.contextGetUser(r).ID,
+		RequestID: a.contextGetRequestID(r),
+	}
+}
+
+// listAuditHandler lists the audit log for catalog admins, newest first. It can be narrowed
+// down with entity, entity_id, actor_id, action, and a from/to time range (a date or RFC 3339)
+func (a *applicationDependencies) listAuditHandler(w http.ResponseWriter, r *http.Request) {
+	var queryParametersData struct {
+		data.AuditCriteria
+		data.Filters
+	}
+
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+
+	queryParametersData.EntityType = a.getSingleQueryParameter(queryParameters, "entity", "")
+	queryParametersData.EntityID = a.getOptionalIntegerParameter(queryParameters, "entity_id", v)
+	queryParametersData.ActorID = a.getOptionalIntegerParameter(queryParameters, "actor_id", v)
+	queryParametersData.Action = a.getSingleQueryParameter(queryParameters, "action", "")
+	queryParametersData.From = a.getOptionalTimeParameter(queryParameters, "from", v)
+	queryParametersData.To = a.getOptionalTimeParameter(queryParameters, "to", v)
+
+	queryParametersData.Filters.Page = a.getSingleIntegerParameter(queryParameters, "page", 1, v)
+	queryParametersData.Filters.PageSize = a.getSingleIntegerParameter(queryParameters, "page_size", 20, v)
+	queryParametersData.Filters.Sort = a.getSingleQueryParameter(queryParameters, "sort", "-created_at")
+	queryParametersData.Filters.SortSafeList = []string{"audit_id", "created_at", "-audit_id", "-created_at"}
+
+	data.ValidateAuditCriteria(v, queryParametersData.AuditCriteria)
+	data.ValidateFilters(v, queryParametersData.Filters)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	entries, metadata, err := a.auditModel.GetAllAuditEntries(queryParametersData.AuditCriteria, queryParametersData.Filters)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Add navigation links for the neighbouring pages
+	headers := a.paginationLinks(r, &metadata)
+
+	data := envelope{
+		"audit_log": entries,
+		"@metadata": metadata,
+	}
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
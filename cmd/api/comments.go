@@ -1,244 +1,374 @@
+// Filename: cmd/api/comments.go
 package main
 
-// import (
-// 	"errors"
-// 	"fmt"
-// 	"net/http"
-
-// 	"github.com/Duane-Arzu/comments/internal/data"
-// 	_ "github.com/Duane-Arzu/comments/internal/data"
-// 	"github.com/Duane-Arzu/comments/internal/validator"
-// 	_ "github.com/Duane-Arzu/comments/internal/validator"
-// )
-
-// func (a *applicationDependences) createCommentHandler(w http.ResponseWriter, r *http.Request) {
-// 	//create a struct to hold a comment
-// 	//we use struct tags [` `] to make the names display in lowercase
-// 	var incomingData struct {
-// 		Content string `json:"content"`
-// 		Author  string `json:"author"`
-// 	}
-
-// 	//perform decoding
-
-// 	err := a.readJSON(w, r, &incomingData)
-// 	if err != nil {
-// 		a.badRequestResponse(w, r, err)
-// 		return
-// 	}
-
-// 	comment := &data.Comment{
-// 		Content: incomingData.Content,
-// 		Author:  incomingData.Author,
-// 	}
-
-// 	v := validator.New()
-// 	//do validation
-// 	data.ValidateComment(v, comment)
-// 	if !v.IsEmpty() {
-// 		a.failedValidationResponse(w, r, v.Errors) //implemented later
-// 		return
-// 	}
-
-// 	//add comment to the comments table in database
-// 	err = a.commentModel.Insert(comment)
-// 	if err != nil {
-// 		a.serverErrorResponse(w, r, err)
-// 		return
-// 	}
-
-// 	//for now display the result
-// 	// fmt.Fprintf(w, "%+v\n", incomingData)
-
-// 	//set a location header, the path to the newly created comments
-// 	headers := make(http.Header)
-// 	headers.Set("Location", fmt.Sprintf("/v1/comments/%d", comment.ID))
-
-// 	//send a json response with a 201 (new reseource created) status code
-// 	data := envelope{
-// 		"comment": comment,
-// 	}
-// 	err = a.writeJSON(w, http.StatusCreated, data, headers)
-// 	if err != nil {
-// 		a.serverErrorResponse(w, r, err)
-// 		return
-// 	}
-// }
-
-// func (a *applicationDependences) fetchCommentByID(w http.ResponseWriter, r *http.Request) (*data.Comment, error) {
-// 	// Get the id from the URL /v1/comments/:id so that we
-// 	// can use it to query the comments table. We will
-// 	// implement the readIDParam() function later
-// 	id, err := a.readIDParam(r)
-// 	if err != nil {
-// 		a.notFoundResponse(w, r)
-
-// 	}
-
-// 	// Call Get() to retrieve the comment with the specified id
-// 	comment, err := a.commentModel.Get(id)
-// 	if err != nil {
-// 		switch {
-// 		case errors.Is(err, data.ErrRecordNotFound):
-// 			a.notFoundResponse(w, r)
-// 		default:
-// 			a.serverErrorResponse(w, r, err)
-// 		}
-
-// 	}
-// 	return comment, nil
-// }
-
-// func (a *applicationDependences) displayCommentHandler(w http.ResponseWriter, r *http.Request) {
-
-// 	comment, err := a.fetchCommentByID(w, r)
-// 	if err != nil {
-// 		return
-// 	}
-// 	// display the comment
-// 	data := envelope{
-// 		"comment": comment,
-// 	}
-// 	err = a.writeJSON(w, http.StatusOK, data, nil)
-// 	if err != nil {
-// 		a.serverErrorResponse(w, r, err)
-// 		return
-// 	}
-
-// }
-
-// func (a *applicationDependences) updateCommentHandler(w http.ResponseWriter, r *http.Request) {
-
-// 	comment, err := a.fetchCommentByID(w, r)
-// 	if err != nil {
-// 		return
-// 	}
-
-// 	// Use our temporary incomingData struct to hold the data
-// 	// Note: I have changed the types to pointer to differentiate
-// 	// between the client leaving a field empty intentionally
-// 	// and the field not needing to be updated
-// 	var incomingData struct {
-// 		Content *string `json:"content"`
-// 		Author  *string `json:"author"`
-// 	}
-
-// 	// perform the decoding
-// 	err = a.readJSON(w, r, &incomingData)
-// 	if err != nil {
-// 		a.badRequestResponse(w, r, err)
-// 		return
-// 	}
-// 	// We need to now check the fields to see which ones need updating
-// 	// if incomingData.Content is nil, no update was provided
-// 	if incomingData.Content != nil {
-// 		comment.Content = *incomingData.Content
-// 	}
-// 	// if incomingData.Author is nil, no update was provided
-// 	if incomingData.Author != nil {
-// 		comment.Author = *incomingData.Author
-// 	}
-
-// 	// Before we write the updates to the DB let's validate
-// 	v := validator.New()
-// 	data.ValidateComment(v, comment)
-// 	if !v.IsEmpty() {
-// 		a.failedValidationResponse(w, r, v.Errors)
-// 		return
-// 	}
-
-// 	// perform the update
-// 	err = a.commentModel.Update(comment)
-// 	if err != nil {
-// 		a.serverErrorResponse(w, r, err)
-// 		return
-// 	}
-// 	data := envelope{
-// 		"comment": comment,
-// 	}
-// 	err = a.writeJSON(w, http.StatusOK, data, nil)
-// 	if err != nil {
-// 		a.serverErrorResponse(w, r, err)
-// 		return
-// 	}
-
-// }
-
-// func (a *applicationDependences) deleteCommentHandler(w http.ResponseWriter, r *http.Request) {
-// 	id, err := a.readIDParam(r)
-// 	if err != nil {
-// 		a.notFoundResponse(w, r)
-// 		return
-// 	}
-// 	err = a.commentModel.Delete(id)
-// 	if err != nil {
-// 		switch {
-// 		case errors.Is(err, data.ErrRecordNotFound):
-// 			a.notFoundResponse(w, r)
-// 		default:
-// 			a.serverErrorResponse(w, r, err)
-// 		}
-// 		return
-// 	}
-
-// 	//diplay the comment
-// 	data := envelope{
-// 		"message": "comment deleted successfully",
-// 	}
-// 	err = a.writeJSON(w, http.StatusOK, data, nil)
-// 	if err != nil {
-// 		a.serverErrorResponse(w, r, err)
-// 	}
-// }
-
-// func (a *applicationDependences) listCommentHandler(w http.ResponseWriter, r *http.Request) {
-// 	//create a struct to hold the query parameters
-// 	//Later, fields will be added for pagination and sorting (filters)
-// 	var queryParameterData struct {
-// 		Content string
-// 		Author  string
-// 		data.Filters
-// 	}
-
-// 	//get query parameters from url
-// 	queryParameter := r.URL.Query()
-
-// 	//load the query parameters into the created struct
-// 	queryParameterData.Content = a.getSingleQueryParameter(queryParameter, "content", "")
-// 	queryParameterData.Author = a.getSingleQueryParameter(queryParameter, "author", "")
-
-// 	// Create a new validator instance
-// 	v := validator.New()
-
-// 	queryParameterData.Filters.Page = a.getSingleIntegerParameter(queryParameter, "page", 1, v)
-// 	queryParameterData.Filters.PageSize = a.getSingleIntegerParameter(queryParameter, "page_size", 10, v)
-
-// 	// Check if our filters are valid
-// 	data.ValidateFilters(v, queryParameterData.Filters)
-// 	if !v.IsEmpty() {
-// 		a.failedValidationResponse(w, r, v.Errors)
-// 		return
-// 	}
-
-// 	//call GetAll to retrieve all comments of the DB
-// 	comments, err := a.commentModel.GetAll(queryParameterData.Content, queryParameterData.Author, queryParameterData.Filters)
-// 	if err != nil {
-// 		switch {
-// 		case errors.Is(err, data.ErrRecordNotFound):
-// 			a.notFoundResponse(w, r)
-// 			return
-// 		default:
-// 			a.serverErrorResponse(w, r, err)
-// 			return
-// 		}
-// 	}
-
-// 	data := envelope{
-// 		"comments": comments,
-// 	}
-// 	err = a.writeJSON(w, http.StatusOK, data, nil)
-// 	if err != nil {
-// 		a.serverErrorResponse(w, r, err)
-// 		return
-// 	}
-// }
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Duane-Arzu/test2/internal/data"
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// fetchReviewByID loads the review named by the :rid URL parameter, as long as the user
+// may see it. When it fails the error response has already been sent and the caller
+// only needs to return
+func (a *applicationDependencies) fetchReviewByID(w http.ResponseWriter, r *http.Request) (*data.Review, error) {
+	id, err := a.readIDParam(r, "rid")
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return nil, err
+	}
+
+	review, err := a.reviewModel.GetReview(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.RRIDnotFound(w, r, id)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return nil, err
+	}
+
+	// Reviews that aren't approved are hidden from everyone but their author and moderators
+	visible, err := a.canViewReview(r, review)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return nil, err
+	}
+	if !visible {
+		a.RRIDnotFound(w, r, id)
+		return nil, data.ErrRecordNotFound
+	}
+	return review, nil
+}
+
+// fetchCommentByID loads the comment named by the :cid URL parameter, making sure it
+// belongs to the review in the URL and that the review may be seen by the user.
+// When it fails the error response has already been sent
+func (a *applicationDependencies) fetchCommentByID(w http.ResponseWriter, r *http.Request) (*data.Comment, error) {
+	review, err := a.fetchReviewByID(w, r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := a.readIDParam(r, "cid")
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return nil, err
+	}
+
+	comment, err := a.commentModel.GetComment(id)
+	if err == nil && comment.ReviewID != review.ReviewID {
+		err = data.ErrRecordNotFound // The comment exists, but not on this review
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return nil, err
+	}
+	return comment, nil
+}
+
+// readCommentDepth reads how many levels of replies to nest from the depth query parameter
+func (a *applicationDependencies) readCommentDepth(r *http.Request, v *validator.Validator) int {
+	depth := a.getSingleIntegerParameter(r.URL.Query(), "depth", 5, v)
+	v.Check(depth > 0, "depth", "must be greater than zero")
+	v.Check(depth <= data.MaxCommentDepth, "depth", fmt.Sprintf("must be a maximum of %d", data.MaxCommentDepth))
+	return depth
+}
+
+// createCommentHandler adds a comment to a review, or a reply to one of its comments
+func (a *applicationDependencies) createCommentHandler(w http.ResponseWriter, r *http.Request) {
+	review, err := a.fetchReviewByID(w, r)
+	if err != nil {
+		return
+	}
+
+	var incomingData struct {
+		ParentID int64  `json:"parent_comment_id"` // optional, the comment being replied to
+		Content  string `json:"content"`
+	}
+
+	err = a.readJSON(w, r, &incomingData)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	// The author is always the authenticated user
+	user := a.contextGetUser(r)
+	comment := &data.Comment{
+		ReviewID: review.ReviewID,
+		ParentID: incomingData.ParentID,
+		UserID:   user.ID,
+		Author:   user.Name,
+		Content:  incomingData.Content,
+	}
+
+	v := validator.New()
+	data.ValidateComment(v, comment)
+
+	// A reply must be to a comment on the same review
+	if comment.ParentID != 0 {
+		parent, err := a.commentModel.GetComment(comment.ParentID)
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("parent_comment_id", "must be an existing comment")
+		case err != nil:
+			a.serverErrorResponse(w, r, err)
+			return
+		case parent.ReviewID != review.ReviewID:
+			v.AddError("parent_comment_id", "must be a comment on the same review")
+		}
+	}
+
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = a.commentModel.InsertComment(comment)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Set a location header, the path to the newly created comment
+	data := envelope{
+		"comment": comment,
+	}
+	etag, err := resourceStateETag("comment", comment.ID, comment.Version, data)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/review/%d/comments/%d", review.ReviewID, comment.ID))
+	headers.Set("ETag", etag)
+
+	err = a.writeJSON(w, r, http.StatusCreated, data, headers)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// listCommentsHandler returns the comments on a review as a tree, nesting replies
+// up to the depth given in the query string
+func (a *applicationDependencies) listCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	review, err := a.fetchReviewByID(w, r)
+	if err != nil {
+		return
+	}
+
+	v := validator.New()
+	depth := a.readCommentDepth(r, v)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	comments, err := a.commentModel.GetAllCommentsForReview(review.ReviewID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	tree := data.CommentTree(comments, 0, depth)
+
+	data := envelope{
+		"comments": tree,
+	}
+	err = a.writeJSON(w, r, http.StatusOK, data, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// displayCommentHandler returns a single comment with its replies nested beneath it.
+// Clients use it to carry on down a thread that was cut off by the depth limit
+func (a *applicationDependencies) displayCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment, err := a.fetchCommentByID(w, r)
+	if err != nil {
+		return
+	}
+
+	v := validator.New()
+	depth := a.readCommentDepth(r, v)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	comments, err := a.commentModel.GetAllCommentsForReview(comment.ReviewID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Nest the thread from the comment's own level so that the comment counts as the first level,
+	// then pick it out from among its siblings
+	for _, sibling := range data.CommentTree(comments, comment.ParentID, depth) {
+		if sibling.ID == comment.ID {
+			comment = sibling
+		}
+	}
+
+	// Replies are added, changed and removed without the comment's version changing, so the
+	// strong ETag covers the whole thread as well. Its version still works with If-Match
+	data := envelope{
+		"comment": comment,
+	}
+	etag, err := resourceStateETag("comment", comment.ID, comment.Version, data)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	headers := make(http.Header)
+	headers.Set("ETag", etag)
+
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateCommentHandler lets the author of a comment, or a moderator, change its content
+func (a *applicationDependencies) updateCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment, err := a.fetchCommentByID(w, r)
+	if err != nil {
+		return
+	}
+
+	// Only the author of the comment or a moderator may change it
+	allowed, err := a.canModify(r, comment.UserID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	if !allowed {
+		a.notPermittedResponse(w, r)
+		return
+	}
+
+	// If the client told us which version it is editing, the update must be made against that version
+	expectedVersion, ok, err := a.readExpectedVersion(r, "comment", comment.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errPreconditionFailed):
+			a.preconditionFailedResponse(w, r)
+		default:
+			a.badRequestResponse(w, r, err)
+		}
+		return
+	}
+	if ok {
+		comment.Version = expectedVersion
+	}
+
+	var incomingData struct {
+		Content *string `json:"content"`
+	}
+
+	err = a.readJSON(w, r, &incomingData)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	if incomingData.Content != nil {
+		comment.Content = *incomingData.Content
+	}
+
+	v := validator.New()
+	data.ValidateComment(v, comment)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = a.commentModel.UpdateComment(comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			a.editConflictResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Send the new ETag so the client can make its next conditional update
+	data := envelope{
+		"comment": comment,
+	}
+	etag, err := resourceStateETag("comment", comment.ID, comment.Version, data)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	headers := make(http.Header)
+	headers.Set("ETag", etag)
+
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteCommentHandler removes a comment and all of the replies beneath it
+func (a *applicationDependencies) deleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment, err := a.fetchCommentByID(w, r)
+	if err != nil {
+		return
+	}
+
+	// Only the author of the comment or a moderator may delete it
+	allowed, err := a.canModify(r, comment.UserID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	if !allowed {
+		a.notPermittedResponse(w, r)
+		return
+	}
+
+	// A conditional delete only goes ahead if nobody has edited the comment since
+	expectedVersion, ok, err := a.readExpectedVersion(r, "comment", comment.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errPreconditionFailed):
+			a.preconditionFailedResponse(w, r)
+		default:
+			a.badRequestResponse(w, r, err)
+		}
+		return
+	}
+	if ok {
+		comment.Version = expectedVersion
+	}
+
+	err = a.commentModel.DeleteComment(comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			a.editConflictResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	data := envelope{
+		"message": "Comment successfully deleted",
+	}
+	err = a.writeJSON(w, r, http.StatusOK, data, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,45 @@
+// Filename: cmd/api/context.go
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Duane-Arzu/test2/internal/data"
+)
+
+// contextKey is our own type so our keys can't collide with other packages
+type contextKey string
+
+const (
+	userContextKey      = contextKey("user")
+	requestIDContextKey = contextKey("request_id")
+)
+
+// contextSetUser returns a copy of the request with the user added to its context
+func (a *applicationDependencies) contextSetUser(r *http.Request, user *data.User) *http.Request {
+	ctx := context.WithValue(r.Context(), userContextKey, user)
+	return r.WithContext(ctx)
+}
+
+// contextGetUser fetches the user that the authenticate middleware stored in the context
+func (a *applicationDependencies) contextGetUser(r *http.Request) *data.User {
+	user, ok := r.Context().Value(userContextKey).(*data.User)
+	if !ok {
+		panic("missing user value in request context")
+	}
+	return user
+}
+
+// contextSetRequestID returns a copy of the request with its request ID added to its context
+func (a *applicationDependencies) contextSetRequestID(r *http.Request, id string) *http.Request {
+	ctx := context.WithValue(r.Context(), requestIDContextKey, id)
+	return r.WithContext(ctx)
+}
+
+// contextGetRequestID fetches the ID that the requestID middleware gave the request,
+// or "" if the request never went through it
+func (a *applicationDependencies) contextGetRequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDContextKey).(string)
+	return id
+}
@@ -10,7 +10,8 @@ func (a *applicationDependencies) logError(r *http.Request, err error) {
 
 	method := r.Method
 	uri := r.URL.RequestURI()
-	a.logger.Error(err.Error(), "method", method, "uri", uri)
+	requestID := a.contextGetRequestID(r)
+	a.logger.Error(err.Error(), "method", method, "uri", uri, "request_id", requestID)
 
 }
 
@@ -20,7 +21,7 @@ func (a *applicationDependencies) errorResponseJSON(w http.ResponseWriter,
 	message any) {
 
 	errorData := envelope{"error": message}
-	err := a.writeJSON(w, status, errorData, nil)
+	err := a.writeJSON(w, r, status, errorData, nil)
 	if err != nil {
 		a.logError(r, err)
 		w.WriteHeader(500)
@@ -88,3 +89,58 @@ func (a *applicationDependencies) failedValidationResponse(w http.ResponseWriter
 	errors map[string]string) {
 	a.errorResponseJSON(w, r, http.StatusUnprocessableEntity, errors)
 }
+
+func (a *applicationDependencies) editConflictResponse(w http.ResponseWriter, r *http.Request) {
+	message := "unable to update the record due to an edit conflict, please try again"
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+func (a *applicationDependencies) preconditionFailedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the If-Match header does not match the current version of this resource"
+	a.errorResponseJSON(w, r, http.StatusPreconditionFailed, message)
+}
+
+func (a *applicationDependencies) duplicateReportResponse(w http.ResponseWriter, r *http.Request) {
+	message := "you have already reported this review"
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+// duplicateReviewResponse tells the user they already reviewed the product and where that review is
+func (a *applicationDependencies) duplicateReviewResponse(w http.ResponseWriter, r *http.Request, existingID int64) {
+	location := fmt.Sprintf("/v1/review/%d", existingID)
+	w.Header().Set("Location", location)
+
+	message := envelope{
+		"message":            "you have already reviewed this product, update your existing review instead",
+		"existing_review_id": existingID,
+		"existing_review":    location,
+	}
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+func (a *applicationDependencies) invalidCredentialsResponse(w http.ResponseWriter, r *http.Request) {
+	message := "invalid authentication credentials"
+	a.errorResponseJSON(w, r, http.StatusUnauthorized, message)
+}
+
+func (a *applicationDependencies) invalidAuthenticationTokenResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+
+	message := "invalid or missing authentication token"
+	a.errorResponseJSON(w, r, http.StatusUnauthorized, message)
+}
+
+func (a *applicationDependencies) authenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	message := "you must be authenticated to access this resource"
+	a.errorResponseJSON(w, r, http.StatusUnauthorized, message)
+}
+
+func (a *applicationDependencies) inactiveAccountResponse(w http.ResponseWriter, r *http.Request) {
+	message := "your user account must be activated to access this resource"
+	a.errorResponseJSON(w, r, http.StatusForbidden, message)
+}
+
+func (a *applicationDependencies) notPermittedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "your user account doesn't have the necessary permissions to access this resource"
+	a.errorResponseJSON(w, r, http.StatusForbidden, message)
+}
@@ -15,7 +15,7 @@ func (a *applicationDependencies) healthcheckHandler(w http.ResponseWriter,
 			"version":     appVersion,
 		},
 	}
-	err := a.writeJSON(w, http.StatusOK, data, nil)
+	err := a.writeJSON(w, r, http.StatusOK, data, nil)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 
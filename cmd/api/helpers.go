@@ -2,15 +2,19 @@
 package main
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Duane-Arzu/test2/internal/data"
 	"github.com/Duane-Arzu/test2/internal/validator"
 	_ "github.com/Duane-Arzu/test2/internal/validator"
 	"github.com/julienschmidt/httprouter"
@@ -18,7 +22,20 @@ import (
 
 type envelope map[string]any
 
-func (a *applicationDependencies) writeJSON(w http.ResponseWriter, status int, data envelope, headers http.Header) error {
+// optionalTime is a time in a partial update that may be cleared. Set tells an explicit
+// null, which clears the time, apart from a field that was left out, which keeps it
+type optionalTime struct {
+	Set   bool
+	Value *time.Time
+}
+
+// UnmarshalJSON is only called for fields present in the body, null included
+func (o *optionalTime) UnmarshalJSON(jsonValue []byte) error {
+	o.Set = true
+	return json.Unmarshal(jsonValue, &o.Value)
+}
+
+func (a *applicationDependencies) writeJSON(w http.ResponseWriter, r *http.Request, status int, data envelope, headers http.Header) error {
 	jsResponse, err := json.MarshalIndent(data, "", "\t")
 	if err != nil {
 		return err
@@ -29,6 +46,21 @@ func (a *applicationDependencies) writeJSON(w http.ResponseWriter, status int, d
 		w.Header()[key] = value
 	}
 
+	// Successful reads get an ETag so clients can revalidate with If-None-Match.
+	// Handlers may set a strong ETag themselves, otherwise we derive a weak one from the body
+	if status == http.StatusOK && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			etag = bodyETag(jsResponse)
+			w.Header().Set("ETag", etag)
+		}
+
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 
 	w.WriteHeader(status)
@@ -159,3 +191,260 @@ func (a *applicationDependencies) getSingleIntegerParameter(queryParameters url.
 
 	return intValue
 }
+
+// getSingleBooleanParameter reads a true/false query parameter such as ?include_review_stats=true
+func (a *applicationDependencies) getSingleBooleanParameter(queryParameters url.Values, key string, defaultValue bool, v *validator.Validator) bool {
+
+	result := queryParameters.Get(key)
+	if result == "" {
+		return defaultValue
+	}
+	// try to convert to a boolean
+	boolValue, err := strconv.ParseBool(result)
+	if err != nil {
+		v.AddError(key, "must be true or false")
+		return defaultValue
+	}
+
+	return boolValue
+}
+
+// resourceETag builds the strong ETag for a single resource from its ID and version,
+// e.g. "product-12-v3". It changes every time the resource is updated
+func resourceETag(kind string, id int64, version int) string {
+	return fmt.Sprintf(`"%s-%d-v%d"`, kind, id, version)
+}
+
+// resourceStateETag builds the strong ETag for a single resource whose response holds more
+// than its versioned fields, such as totals kept by a trigger, e.g. "product-12-v3-9f86d081884c7d65".
+// A hash of the response data follows the version, so the tag changes whenever the body does,
+// while readExpectedVersion can still take the version from it for If-Match
+func resourceStateETag(kind string, id int64, version int, data envelope) (string, error) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(js)
+	return fmt.Sprintf(`"%s-%d-v%d-%x"`, kind, id, version, hash[:8]), nil
+}
+
+// bodyETag builds a weak ETag from the response body. It is used for list responses,
+// whose content depends on the whole result set rather than a single version
+func bodyETag(body []byte) string {
+	hash := sha256.Sum256(body)
+	return fmt.Sprintf(`W/"%x"`, hash[:16])
+}
+
+// etagMatches reports whether any of the tags in an If-None-Match header matches the ETag.
+// If-None-Match uses weak comparison, so the W/ prefix is ignored on both sides
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// errPreconditionFailed is returned by readExpectedVersion when none of the If-Match
+// ETags belong to the resource being changed
+var errPreconditionFailed = errors.New("precondition failed")
+
+// readExpectedVersion returns the version the client expects the resource to be at.
+// It is taken from the X-Expected-Version header or, failing that, from an If-Match
+// header holding the resource's ETag. ok is false when the client asked for no check
+func (a *applicationDependencies) readExpectedVersion(r *http.Request, kind string, id int64) (int, bool, error) {
+	if header := r.Header.Get("X-Expected-Version"); header != "" {
+		version, err := strconv.Atoi(header)
+		if err != nil || version < 1 {
+			return 0, false, errors.New("X-Expected-Version header must be a positive integer")
+		}
+		return version, true, nil
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" || strings.TrimSpace(ifMatch) == "*" {
+		return 0, false, nil
+	}
+
+	// look for an ETag that belongs to this resource and pull the version out of it,
+	// ignoring the state hash that follows it in a resourceStateETag
+	prefix := fmt.Sprintf(`"%s-%d-v`, kind, id)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if !strings.HasPrefix(tag, prefix) || !strings.HasSuffix(tag, `"`) {
+			continue
+		}
+		digits, _, _ := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(tag, prefix), `"`), "-")
+		version, err := strconv.Atoi(digits)
+		if err == nil {
+			return version, true, nil
+		}
+	}
+
+	// none of the ETags match this resource, so no version can satisfy the request
+	return 0, false, errPreconditionFailed
+}
+
+// getOptionalIntegerParameter reads an integer query parameter, returning nil when it is absent
+func (a *applicationDependencies) getOptionalIntegerParameter(queryParameters url.Values, key string, v *validator.Validator) *int64 {
+
+	result := queryParameters.Get(key)
+	if result == "" {
+		return nil
+	}
+	// try to convert to an integer
+	intValue, err := strconv.ParseInt(result, 10, 64)
+	if err != nil {
+		v.AddError(key, "must be an integer value")
+		return nil
+	}
+
+	return &intValue
+}
+
+// getOptionalFloatParameter reads a decimal query parameter, returning nil when it is absent
+func (a *applicationDependencies) getOptionalFloatParameter(queryParameters url.Values, key string, v *validator.Validator) *float64 {
+
+	result := queryParameters.Get(key)
+	if result == "" {
+		return nil
+	}
+	// try to convert to a float
+	floatValue, err := strconv.ParseFloat(result, 64)
+	if err != nil {
+		v.AddError(key, "must be a decimal value")
+		return nil
+	}
+
+	return &floatValue
+}
+
+// getOptionalTimeParameter reads a timestamp query parameter given either as RFC 3339
+// (2024-01-02T15:04:05Z) or as a plain date (2024-01-02), returning nil when it is absent
+func (a *applicationDependencies) getOptionalTimeParameter(queryParameters url.Values, key string, v *validator.Validator) *time.Time {
+
+	result := queryParameters.Get(key)
+	if result == "" {
+		return nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		timeValue, err := time.Parse(layout, result)
+		if err == nil {
+			return &timeValue
+		}
+	}
+
+	v.AddError(key, "must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+	return nil
+}
+
+// getOptionalPriceParameter reads a decimal price such as 12.99 in the given currency
+// and returns it in minor units, returning nil when it is absent
+func (a *applicationDependencies) getOptionalPriceParameter(queryParameters url.Values, key string, currency string, v *validator.Validator) *int64 {
+
+	result := queryParameters.Get(key)
+	if result == "" {
+		return nil
+	}
+
+	price, err := data.ParseMoney(result, currency)
+	if err != nil {
+		v.AddError(key, err.Error())
+		return nil
+	}
+
+	return &price.Amount
+}
+
+// fromTrustedProxy reports whether the request came straight from one of the reverse
+// proxies named by -trusted-proxies
+func (a *applicationDependencies) fromTrustedProxy(r *http.Request) bool {
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, prefix := range a.config.proxy.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// paginationLinks fills in the navigation URLs of a list response's metadata and
+// returns them as an RFC 5988 Link header. Every query parameter of the current
+// request (filters, sort, page_size) is carried over; only page and cursor change
+func (a *applicationDependencies) paginationLinks(r *http.Request, metadata *data.Metadata) http.Header {
+	headers := make(http.Header)
+
+	// work out the absolute URL of this endpoint, honouring a TLS-terminating proxy.
+	// Anyone can send X-Forwarded-Proto, so it only counts when a trusted proxy sent it
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	} else if a.fromTrustedProxy(r) {
+		switch proto := strings.ToLower(r.Header.Get("X-Forwarded-Proto")); proto {
+		case "http", "https":
+			scheme = proto
+		}
+	}
+
+	pageURL := func(key string, value string) string {
+		query := r.URL.Query()
+		query.Del("page")
+		query.Del("cursor")
+		query.Set(key, value)
+		link := url.URL{Scheme: scheme, Host: r.Host, Path: r.URL.Path, RawQuery: query.Encode()}
+		return link.String()
+	}
+
+	// page numbers are only known when paging by offset, cursors work either way
+	if metadata.TotalRecords > 0 || metadata.NextCursor != "" || metadata.PrevCursor != "" {
+		metadata.FirstURL = pageURL("page", "1")
+	}
+	// a last page past the page limit can't be requested, so leave the link out
+	if metadata.LastPage > 0 && metadata.LastPage <= data.MaxPage {
+		metadata.LastURL = pageURL("page", strconv.Itoa(metadata.LastPage))
+	}
+	switch {
+	case metadata.CurrentPage > 1:
+		metadata.PrevURL = pageURL("page", strconv.Itoa(metadata.CurrentPage-1))
+	case metadata.PrevCursor != "":
+		metadata.PrevURL = pageURL("cursor", metadata.PrevCursor)
+	}
+	switch {
+	case metadata.CurrentPage > 0 && metadata.CurrentPage < metadata.LastPage && metadata.CurrentPage < data.MaxPage:
+		metadata.NextURL = pageURL("page", strconv.Itoa(metadata.CurrentPage+1))
+	case metadata.NextCursor != "":
+		// past the page limit clients carry on with the cursor
+		metadata.NextURL = pageURL("cursor", metadata.NextCursor)
+	}
+
+	var links []string
+	for _, link := range []struct{ rel, url string }{
+		{"first", metadata.FirstURL},
+		{"prev", metadata.PrevURL},
+		{"next", metadata.NextURL},
+		{"last", metadata.LastURL},
+	} {
+		if link.url != "" {
+			links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, link.url, link.rel))
+		}
+	}
+	if len(links) > 0 {
+		headers.Set("Link", strings.Join(links, ", "))
+	}
+
+	return headers
+}
@@ -0,0 +1,65 @@
+// Filename: cmd/api/jobs.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// startBackgroundJobs starts the jobs that run alongside the server. They stop once ctx
+// is cancelled, and serve() waits for a run that is under way to finish
+func (a *applicationDependencies) startBackgroundJobs(ctx context.Context) {
+	if a.config.trash.purgeInterval > 0 {
+		a.runPeriodically(ctx, "purge trash", a.config.trash.purgeInterval, a.purgeTrash)
+	}
+	if a.config.publish.interval > 0 {
+		a.runPeriodically(ctx, "publish scheduled products", a.config.publish.interval, a.publishScheduledProducts)
+	}
+}
+
+// runPeriodically runs the job straight away and then every interval until ctx is cancelled.
+// A failed or panicking run is logged and the job carries on with its next run
+func (a *applicationDependencies) runPeriodically(ctx context.Context, name string, interval time.Duration, job func() error) {
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			a.runJob(name, job)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// runInBackground runs a one-off job, such as sending an email, without holding up the
+// response. serve() waits for it to finish before the server exits
+func (a *applicationDependencies) runInBackground(name string, job func() error) {
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.runJob(name, job)
+	}()
+}
+
+// runJob runs a single run of a background job, logging its error or panic
+func (a *applicationDependencies) runJob(name string, job func() error) {
+	defer func() {
+		if err := recover(); err != nil {
+			a.logger.Error(fmt.Sprintf("%v", err), "job", name)
+		}
+	}()
+
+	err := job()
+	if err != nil {
+		a.logger.Error(err.Error(), "job", name)
+	}
+}
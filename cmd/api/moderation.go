@@ -0,0 +1,106 @@
+// Filename: cmd/api/moderation.go
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Duane-Arzu/test2/internal/data"
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// listReviewQueueHandler lists reviews for moderators. By default it shows the queue of
+// pending reviews, oldest first; the status parameter selects the reviews in another state
+func (a *applicationDependencies) listReviewQueueHandler(w http.ResponseWriter, r *http.Request) {
+	status := a.getSingleQueryParameter(r.URL.Query(), "status", data.ReviewStatusPending)
+
+	v := validator.New()
+	v.Check(validator.PermittedValue(status, data.ReviewStatuses...), "status", "must be one of pending, approved, rejected or hidden")
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	a.listReviews(w, r, status, "created_at")
+}
+
+// moderateReviewHandler records a moderator's decision on a review, e.g.
+// {"status": "rejected", "reason": "Advertises another shop"}
+func (a *applicationDependencies) moderateReviewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r, "rid")
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	review, err := a.reviewModel.GetReview(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.RRIDnotFound(w, r, id)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// If the client told us which version it is moderating, the decision must be made against that version
+	expectedVersion, ok, err := a.readExpectedVersion(r, "review", review.ReviewID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errPreconditionFailed):
+			a.preconditionFailedResponse(w, r)
+		default:
+			a.badRequestResponse(w, r, err)
+		}
+		return
+	}
+	if ok {
+		review.Version = expectedVersion
+	}
+
+	var incomingData struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+
+	err = a.readJSON(w, r, &incomingData)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	review.Status = incomingData.Status
+	review.ModerationReason = incomingData.Reason
+	review.ModeratedBy = a.contextGetUser(r).ID
+
+	v := validator.New()
+	data.ValidateModeration(v, review)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = a.reviewModel.ModerateReview(review)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			a.editConflictResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Send the new ETag so the client can make its next conditional update
+	headers := make(http.Header)
+	headers.Set("ETag", resourceETag("review", review.ReviewID, review.Version))
+
+	data := envelope{
+		"review": review,
+	}
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
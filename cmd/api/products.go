@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/Duane-Arzu/test2/internal/data"
 	_ "github.com/Duane-Arzu/test2/internal/data"
@@ -14,12 +15,13 @@ import (
 // Product represents the expected structure for incoming product data with optional fields
 // Using pointers allows us to distinguish between empty values and omitted fields in PATCH requests
 type incomingProductData struct {
-	Name        *string  `json:"name"`
-	Description *string  `json:"description"`
-	Category    *string  `json:"category"`
-	ImageURL    *string  `json:"image_url"`
-	Price       *string  `json:"price"`
-	AvgRating   *float32 `json:"avg_rating"`
+	Name        *string     `json:"name"`
+	Description *string     `json:"description"`
+	Category    *string     `json:"category"`
+	ImageURL    *string     `json:"image_url"`
+	Price       *data.Money `json:"price"`
+	Currency    *string     `json:"currency"`
+	AvgRating   *float32    `json:"avg_rating"`
 }
 
 // createProductHandler handles POST requests to create new products
@@ -28,11 +30,14 @@ func (a *applicationDependencies) createProductHandler(w http.ResponseWriter, r
 	// Define structure for incoming product creation data
 	// Note: All fields are required for creation
 	var incomingProductData struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
-		Category    string `json:"category"`
-		ImageURL    string `json:"image_url"`
-		Price       string `json:"price"`
+		Name        string     `json:"name"`
+		Description string     `json:"description"`
+		Category    string     `json:"category"`
+		ImageURL    string     `json:"image_url"`
+		Price       data.Money `json:"price"`
+		Currency    string     `json:"currency"`
+		Status      string     `json:"status"`     // Optional, new products are drafts unless published straight away
+		PublishAt   *time.Time `json:"publish_at"` // Optional, when the scheduler should publish a draft
 	}
 
 	// Parse JSON request body into our data structure
@@ -49,10 +54,27 @@ func (a *applicationDependencies) createProductHandler(w http.ResponseWriter, r
 		Category:    incomingProductData.Category,
 		ImageURL:    incomingProductData.ImageURL,
 		Price:       incomingProductData.Price,
+		Status:      incomingProductData.Status,
+		PublishAt:   incomingProductData.PublishAt,
+	}
+	if product.Status == "" {
+		product.Status = data.ProductStatusDraft
+	}
+
+	// The currency field wins over one given inside the price, otherwise fall back to the default
+	if incomingProductData.Currency != "" {
+		product.Price.Currency = incomingProductData.Currency
+	}
+	if product.Price.Currency == "" {
+		product.Price.Currency = data.DefaultCurrency
 	}
 
 	// Validate the product data using our validation package
 	v := validator.New()
+	err = product.Price.Resolve()
+	if err != nil {
+		v.AddError("price", err.Error())
+	}
 	data.ValidateProduct(v, product)
 	if !v.IsEmpty() {
 		a.failedValidationResponse(w, r, v.Errors)
@@ -60,7 +82,7 @@ func (a *applicationDependencies) createProductHandler(w http.ResponseWriter, r
 	}
 
 	// Insert the validated product into the database
-	err = a.productModel.InsertProduct(product)
+	err = a.productModel.InsertProduct(product, a.auditActor(r))
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
@@ -74,7 +96,7 @@ func (a *applicationDependencies) createProductHandler(w http.ResponseWriter, r
 	data := envelope{
 		"Product": product,
 	}
-	err = a.writeJSON(w, http.StatusCreated, data, headers)
+	err = a.writeJSON(w, r, http.StatusCreated, data, headers)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
@@ -102,11 +124,52 @@ func (a *applicationDependencies) displayProductHandler(w http.ResponseWriter, r
 		return
 	}
 
+	// Drafts and archived products are only shown to catalog admins
+	if product.Status != data.ProductStatusPublished {
+		admin, err := a.isCatalogAdmin(r)
+		if err != nil {
+			a.serverErrorResponse(w, r, err)
+			return
+		}
+		if !admin {
+			a.notFoundResponse(w, r)
+			return
+		}
+	}
+
+	// Clients can ask for the review statistics to be sent along with the product
+	v := validator.New()
+	includeStats := a.getSingleBooleanParameter(r.URL.Query(), "include_review_stats", false, v)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
 	// Return the found product in the response
 	data := envelope{
 		"Product": product,
 	}
-	err = a.writeJSON(w, http.StatusOK, data, nil)
+
+	if includeStats {
+		stats, err := a.reviewModel.GetReviewStats(product.ProductID)
+		if err != nil {
+			a.serverErrorResponse(w, r, err)
+			return
+		}
+		data["review_stats"] = stats
+	}
+
+	// The rating totals, score and statistics change as reviews come and go without the
+	// product's version changing, so the strong ETag covers the whole response as well
+	etag, err := resourceStateETag("product", product.ProductID, int(product.Version), data)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	headers := make(http.Header)
+	headers.Set("ETag", etag)
+
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
@@ -133,13 +196,31 @@ func (a *applicationDependencies) updateProductHandler(w http.ResponseWriter, r
 		return
 	}
 
+	// If the client told us which version it is editing, the update must be made against that version
+	expectedVersion, ok, err := a.readExpectedVersion(r, "product", product.ProductID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errPreconditionFailed):
+			a.preconditionFailedResponse(w, r)
+		default:
+			a.badRequestResponse(w, r, err)
+		}
+		return
+	}
+	if ok {
+		product.Version = int32(expectedVersion)
+	}
+
 	// Define structure for partial updates using pointer fields
 	var incomingProductData struct {
-		Name        *string `json:"name"`
-		Description *string `json:"description"`
-		Category    *string `json:"category"`
-		ImageURL    *string `json:"image_url"`
-		Price       *string `json:"price"`
+		Name        *string      `json:"name"`
+		Description *string      `json:"description"`
+		Category    *string      `json:"category"`
+		ImageURL    *string      `json:"image_url"`
+		Price       *data.Money  `json:"price"`
+		Currency    *string      `json:"currency"`
+		Status      *string      `json:"status"`
+		PublishAt   optionalTime `json:"publish_at"` // null clears the publishing time
 		// Commented fields can be uncommented when needed
 		//UpdatedAt   *time.Time `json:"updated_at"`
 		//AvgRating *float64   `json:"avg_rating"`
@@ -165,12 +246,31 @@ func (a *applicationDependencies) updateProductHandler(w http.ResponseWriter, r
 	if incomingProductData.ImageURL != nil {
 		product.ImageURL = *incomingProductData.ImageURL
 	}
+	// A new price without a currency keeps the product's current currency
 	if incomingProductData.Price != nil {
+		currency := product.Price.Currency
 		product.Price = *incomingProductData.Price
+		if product.Price.Currency == "" {
+			product.Price.Currency = currency
+		}
+	}
+	// Changing only the currency re-reads the current amount in the new currency
+	if incomingProductData.Currency != nil {
+		product.Price = product.Price.InCurrency(*incomingProductData.Currency)
+	}
+	if incomingProductData.Status != nil {
+		product.Status = *incomingProductData.Status
+	}
+	if incomingProductData.PublishAt.Set {
+		product.PublishAt = incomingProductData.PublishAt.Value
 	}
 
 	// Validate the updated product data
 	v := validator.New()
+	err = product.Price.Resolve()
+	if err != nil {
+		v.AddError("price", err.Error())
+	}
 	data.ValidateProduct(v, product)
 	if !v.IsEmpty() {
 		a.failedValidationResponse(w, r, v.Errors)
@@ -178,17 +278,26 @@ func (a *applicationDependencies) updateProductHandler(w http.ResponseWriter, r
 	}
 
 	// Save the updated product to the database
-	err = a.productModel.UpdateProduct(product)
+	err = a.productModel.UpdateProduct(product, a.auditActor(r))
 	if err != nil {
-		a.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			a.editConflictResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
+	// Send the new ETag so the client can make its next conditional update
+	headers := make(http.Header)
+	headers.Set("ETag", resourceETag("product", product.ProductID, int(product.Version)))
+
 	// Return the updated product in the response
 	data := envelope{
 		"Product": product,
 	}
-	err = a.writeJSON(w, http.StatusOK, data, nil)
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
@@ -205,7 +314,7 @@ func (a *applicationDependencies) deleteProductHandler(w http.ResponseWriter, r
 	}
 
 	// Attempt to delete the product from the database
-	err = a.productModel.DeleteProduct(id)
+	err = a.productModel.DeleteProduct(id, a.auditActor(r))
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -220,35 +329,96 @@ func (a *applicationDependencies) deleteProductHandler(w http.ResponseWriter, r
 	data := envelope{
 		"message": "Product successfully deleted",
 	}
-	err = a.writeJSON(w, http.StatusOK, data, nil)
+	err = a.writeJSON(w, r, http.StatusOK, data, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// restoreProductHandler handles POST requests to take a product, and its reviews, back out of the trash
+func (a *applicationDependencies) restoreProductHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r, "pid")
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	err = a.productModel.RestoreProduct(id, a.auditActor(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.PRIDnotFound(w, r, id)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Read the product back so the response includes its ratings and score
+	product, err := a.productModel.GetProduct(id)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", resourceETag("product", product.ProductID, int(product.Version)))
+
+	data := envelope{
+		"product": product,
+	}
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
 }
 
 // listProductHandler handles GET requests to retrieve a filtered, paginated list of products
-// Supports filtering by name and category, with sorting and pagination options
+// Supports filtering by name, categories, price range, rating and creation date, with sorting and pagination options
 func (a *applicationDependencies) listProductHandler(w http.ResponseWriter, r *http.Request) {
 	// Define structure to hold query parameters and filtering options
 	var queryParametersData struct {
-		Name     string
-		Category string
+		data.ProductCriteria
 		data.Filters
 	}
 
 	// Extract query parameters from the URL
 	queryParameters := r.URL.Query()
+	v := validator.New()
 	queryParametersData.Name = a.getSingleQueryParameter(queryParameters, "name", "")
-	queryParametersData.Category = a.getSingleQueryParameter(queryParameters, "category", "")
+	queryParametersData.Categories = a.getMultipleQueryParameters(queryParameters, "category", []string{})
+	queryParametersData.Currency = a.getSingleQueryParameter(queryParameters, "currency", data.DefaultCurrency)
+	queryParametersData.MinRating = a.getOptionalFloatParameter(queryParameters, "min_rating", v)
+	queryParametersData.CreatedAfter = a.getOptionalTimeParameter(queryParameters, "created_after", v)
+	queryParametersData.CreatedBefore = a.getOptionalTimeParameter(queryParameters, "created_before", v)
+	// Price bounds are decimal amounts in the requested currency
+	queryParametersData.MinPrice = a.getOptionalPriceParameter(queryParameters, "min_price", queryParametersData.Currency, v)
+	queryParametersData.MaxPrice = a.getOptionalPriceParameter(queryParameters, "max_price", queryParametersData.Currency, v)
+
+	// The public only sees published products, catalog admins see every product unless they pick a status
+	admin, err := a.isCatalogAdmin(r)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	queryParametersData.Status = data.ProductStatusPublished
+	if admin {
+		queryParametersData.Status = a.getSingleQueryParameter(queryParameters, "status", "")
+	}
 
 	// Set up and validate pagination and sorting parameters
-	v := validator.New()
 	queryParametersData.Filters.Page = a.getSingleIntegerParameter(queryParameters, "page", 1, v)
 	queryParametersData.Filters.PageSize = a.getSingleIntegerParameter(queryParameters, "page_size", 10, v)
 	queryParametersData.Filters.Sort = a.getSingleQueryParameter(queryParameters, "sort", "product_id")
-	queryParametersData.Filters.SortSafeList = []string{"product_id", "name", "-product_id", "-name"}
+	queryParametersData.Filters.Cursor = a.getSingleQueryParameter(queryParameters, "cursor", "")
+	queryParametersData.Filters.CursorKey = []byte(a.config.cursor.secret)
+	queryParametersData.Filters.SortSafeList = []string{
+		"product_id", "name", "price", "avg_rating", "score", "created_at", "review_count",
+		"-product_id", "-name", "-price", "-avg_rating", "-score", "-created_at", "-review_count",
+	}
 
 	// Validate the filters
+	data.ValidateProductCriteria(v, queryParametersData.ProductCriteria)
 	data.ValidateFilters(v, queryParametersData.Filters)
 	if !v.IsEmpty() {
 		a.failedValidationResponse(w, r, v.Errors)
@@ -257,8 +427,7 @@ func (a *applicationDependencies) listProductHandler(w http.ResponseWriter, r *h
 
 	// Retrieve filtered and paginated products from the database
 	products, metadata, err := a.productModel.GetAllProducts(
-		queryParametersData.Name,
-		queryParametersData.Category,
+		queryParametersData.ProductCriteria,
 		queryParametersData.Filters,
 	)
 	if err != nil {
@@ -266,13 +435,35 @@ func (a *applicationDependencies) listProductHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	// Add navigation links for the neighbouring pages
+	headers := a.paginationLinks(r, &metadata)
+
 	// Return the products and metadata in the response
 	data := envelope{
 		"products":  products,
 		"@metadata": metadata,
 	}
-	err = a.writeJSON(w, http.StatusOK, data, nil)
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
 }
+
+// isCatalogAdmin reports whether the authenticated user may manage products, and so see
+// the ones that aren't published
+func (a *applicationDependencies) isCatalogAdmin(r *http.Request) (bool, error) {
+	return a.hasPermission(r, data.PermissionProductsWrite)
+}
+
+// publishScheduledProducts is the background job that publishes drafts once their
+// publish_at time arrives
+func (a *applicationDependencies) publishScheduledProducts() error {
+	published, err := a.productModel.PublishScheduled(data.AuditActor{})
+	if err != nil {
+		return err
+	}
+	if published > 0 {
+		a.logger.Info("published scheduled products", "products", published)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Filename: cmd/api/reports.go
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Duane-Arzu/test2/internal/data"
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// createReportHandler lets a user report a review, e.g. {"reason": "spam", "details": "..."}.
+// Each user can report a review once
+func (a *applicationDependencies) createReportHandler(w http.ResponseWriter, r *http.Request) {
+	review, err := a.fetchReviewByID(w, r)
+	if err != nil {
+		return
+	}
+
+	var incomingData struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+
+	err = a.readJSON(w, r, &incomingData)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := a.contextGetUser(r)
+	report := &data.ReviewReport{
+		ReviewID: review.ReviewID,
+		UserID:   user.ID,
+		Reason:   incomingData.Reason,
+		Details:  incomingData.Details,
+	}
+
+	v := validator.New()
+	data.ValidateReviewReport(v, report)
+	v.Check(review.UserID != user.ID, "reason", "you cannot report your own review")
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	hidden, err := a.reviewReportModel.InsertReport(report, a.config.reports.hideThreshold)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateReport):
+			a.duplicateReportResponse(w, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.RRIDnotFound(w, r, review.ReviewID) // The review was deleted in the meantime
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if hidden {
+		a.logger.Info("review hidden after reports", "review_id", review.ReviewID)
+	}
+
+	data := envelope{
+		"report": report,
+	}
+	err = a.writeJSON(w, r, http.StatusCreated, data, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// listReportedReviewsHandler lists reported reviews for moderators, most reported first
+func (a *applicationDependencies) listReportedReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	var queryParametersData struct {
+		Status string
+		data.Filters
+	}
+
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+
+	// Reported reviews in any status are listed unless the moderator picks one
+	queryParametersData.Status = a.getSingleQueryParameter(queryParameters, "status", "")
+	if queryParametersData.Status != "" {
+		v.Check(validator.PermittedValue(queryParametersData.Status, data.ReviewStatuses...), "status", "must be one of pending, approved, rejected or hidden")
+	}
+
+	queryParametersData.Filters.Page = a.getSingleIntegerParameter(queryParameters, "page", 1, v)
+	queryParametersData.Filters.PageSize = a.getSingleIntegerParameter(queryParameters, "page_size", 10, v)
+	queryParametersData.Filters.Sort = a.getSingleQueryParameter(queryParameters, "sort", "-report_count")
+	queryParametersData.Filters.SortSafeList = []string{
+		"review_id", "report_count", "last_reported_at",
+		"-review_id", "-report_count", "-last_reported_at",
+	}
+
+	data.ValidateFilters(v, queryParametersData.Filters)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	reported, metadata, err := a.reviewReportModel.GetReportedReviews(queryParametersData.Status, queryParametersData.Filters)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Add navigation links for the neighbouring pages
+	headers := a.paginationLinks(r, &metadata)
+
+	data := envelope{
+		"reported_reviews": reported,
+		"@metadata":        metadata,
+	}
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
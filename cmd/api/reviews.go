@@ -24,12 +24,12 @@ var incomingReviewData struct {
 // Updated createReviewHandler with product existence check
 func (a *applicationDependencies) createReviewHandler(w http.ResponseWriter, r *http.Request) {
 	// Create a local instance of incomingReviewData
+	// The author is no longer accepted from the client, it comes from the authenticated user
+	// The helpful count is no longer accepted either, it is counted from votes
 	var incomingReviewData struct {
-		ProductID    *int64  `json:"product_id"`
-		Author       *string `json:"author"`
-		Rating       *int64  `json:"rating"`
-		HelpfulCount *int32  `json:"helpful_count"`
-		Comment      *string `json:"comment"`
+		ProductID *int64  `json:"product_id"`
+		Rating    *int64  `json:"rating"`
+		Comment   *string `json:"comment"`
 	}
 
 	// Decode the incoming JSON into the struct
@@ -56,18 +56,15 @@ func (a *applicationDependencies) createReviewHandler(w http.ResponseWriter, r *
 		return
 	}
 
-	if incomingReviewData.HelpfulCount == nil {
-		incomingReviewData.HelpfulCount = new(int32) // Default to 0 if not provided
-	}
-
 	// Create the review object based on the incoming data
+	user := a.contextGetUser(r)
 	review := &data.Review{
-		ProductID:    int64(*incomingReviewData.ProductID),
-		Author:       *incomingReviewData.Author,
-		Rating:       int64(*incomingReviewData.Rating),
-		Comment:      *incomingReviewData.Comment,
-		HelpfulCount: int32(*incomingReviewData.HelpfulCount),
-		CreatedAt:    time.Now(),
+		ProductID: int64(*incomingReviewData.ProductID),
+		UserID:    user.ID,
+		Author:    user.Name,
+		Rating:    int64(*incomingReviewData.Rating),
+		Comment:   *incomingReviewData.Comment,
+		CreatedAt: time.Now(),
 	}
 
 	// Initialize a Validator instance
@@ -80,10 +77,34 @@ func (a *applicationDependencies) createReviewHandler(w http.ResponseWriter, r *
 		return
 	}
 
+	// A user reviews a product once, after that they edit their review
+	existing, err := a.reviewModel.GetUserProductReview(user.ID, review.ProductID)
+	switch {
+	case err == nil:
+		a.duplicateReviewResponse(w, r, existing.ReviewID)
+		return
+	case !errors.Is(err, data.ErrRecordNotFound):
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Screening decides whether the review is published, rejected or queued for a moderator
+	err = a.screenReview(review)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
 	// Insert the review into the database
 	err = a.reviewModel.InsertReview(review)
 	if err != nil {
-		a.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrDuplicateReview):
+			// Another request created the user's review since we checked
+			a.respondWithExistingReview(w, r, user.ID, review.ProductID)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -94,13 +115,147 @@ func (a *applicationDependencies) createReviewHandler(w http.ResponseWriter, r *
 	data := envelope{
 		"Review": review,
 	}
-	err = a.writeJSON(w, http.StatusCreated, data, headers)
+	err = a.writeJSON(w, r, http.StatusCreated, data, headers)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
 	}
 }
 
+// myReviewHandler creates or replaces the authenticated user's review of a product,
+// e.g. PUT /v1/product/:pid/my-review with {"rating": 4, "comment": "..."}.
+// An If-Match or X-Expected-Version header makes the replacement conditional on the version
+func (a *applicationDependencies) myReviewHandler(w http.ResponseWriter, r *http.Request) {
+	pid, err := a.readIDParam(r, "pid")
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	exists, err := a.productModel.ProductExists(pid)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	if !exists {
+		a.PRIDnotFound(w, r, pid)
+		return
+	}
+
+	var incomingReviewData struct {
+		Rating  *int64  `json:"rating"`
+		Comment *string `json:"comment"`
+	}
+
+	err = a.readJSON(w, r, &incomingReviewData)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	// The whole review is replaced, so both fields are needed
+	v := validator.New()
+	v.Check(incomingReviewData.Rating != nil, "rating", "must be provided")
+	v.Check(incomingReviewData.Comment != nil, "comment", "must be provided")
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user := a.contextGetUser(r)
+	review := &data.Review{
+		ProductID: pid,
+		UserID:    user.ID,
+		Author:    user.Name,
+		Rating:    *incomingReviewData.Rating,
+		Comment:   *incomingReviewData.Comment,
+	}
+
+	data.ValidateReview(v, review)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// Look up the current review so screening doesn't flag the text as a duplicate of itself,
+	// can't lift a rejected or hidden review past pending, and so the client's ETag can be
+	// matched against it
+	existing, err := a.reviewModel.GetUserProductReview(user.ID, pid)
+	switch {
+	case err == nil:
+		review.ReviewID = existing.ReviewID
+		review.Status = existing.Status
+	case !errors.Is(err, data.ErrRecordNotFound):
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	expectedVersion, ok, err := a.readExpectedVersion(r, "review", review.ReviewID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errPreconditionFailed):
+			a.preconditionFailedResponse(w, r)
+		default:
+			a.badRequestResponse(w, r, err)
+		}
+		return
+	}
+	if ok && existing == nil {
+		// The client expected a review that isn't there
+		a.editConflictResponse(w, r)
+		return
+	}
+	if !ok && existing != nil {
+		// The review was screened against the status it had when it was read, so it may only
+		// replace that version; a moderator's decision made since then is an edit conflict
+		expectedVersion = existing.Version
+	}
+
+	err = a.screenReview(review)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	created, err := a.reviewModel.UpsertReview(review, expectedVersion)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			a.editConflictResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", resourceETag("review", review.ReviewID, review.Version))
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		headers.Set("Location", fmt.Sprintf("/v1/review/%d", review.ReviewID))
+	}
+
+	data := envelope{
+		"review": review,
+	}
+	err = a.writeJSON(w, r, status, data, headers)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// respondWithExistingReview sends the 409 for a user who already reviewed the product
+func (a *applicationDependencies) respondWithExistingReview(w http.ResponseWriter, r *http.Request, userID int64, productID int64) {
+	existing, err := a.reviewModel.GetUserProductReview(userID, productID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	a.duplicateReviewResponse(w, r, existing.ReviewID)
+}
+
 func (a *applicationDependencies) displayReviewHandler(w http.ResponseWriter, r *http.Request) {
 	// Get the id from the URL /v1/comments/:id so that we
 	// can use it to query teh comments table. We will
@@ -123,11 +278,33 @@ func (a *applicationDependencies) displayReviewHandler(w http.ResponseWriter, r
 		return
 	}
 
+	// Reviews that aren't approved are only shown to their author and to moderators
+	visible, err := a.canViewReview(r, review)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	if !visible {
+		a.notFoundResponse(w, r)
+		return
+	}
+
 	// display the comment
 	data := envelope{
 		"Review": review,
 	}
-	err = a.writeJSON(w, http.StatusOK, data, nil)
+
+	// Votes change the helpful and unhelpful counts without changing the review's version,
+	// so the strong ETag covers the whole response as well
+	etag, err := resourceStateETag("review", review.ReviewID, review.Version, data)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	headers := make(http.Header)
+	headers.Set("ETag", etag)
+
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
@@ -154,9 +331,34 @@ func (a *applicationDependencies) updateReviewHandler(w http.ResponseWriter, r *
 		return
 	}
 
+	// Only the author of the review or a moderator may change it
+	allowed, err := a.canModifyReview(r, review)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	if !allowed {
+		a.notPermittedResponse(w, r)
+		return
+	}
+
+	// If the client told us which version it is editing, the update must be made against that version
+	expectedVersion, ok, err := a.readExpectedVersion(r, "review", review.ReviewID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errPreconditionFailed):
+			a.preconditionFailedResponse(w, r)
+		default:
+			a.badRequestResponse(w, r, err)
+		}
+		return
+	}
+	if ok {
+		review.Version = expectedVersion
+	}
+
 	// Define a struct to hold incoming JSON data
 	var incomingReviewData struct {
-		Author  *string `json:"author"`
 		Rating  *int64  `json:"rating"`  // integer with a constraint (1-5)
 		Comment *string `json:"comment"` // non-null text field
 	}
@@ -169,9 +371,6 @@ func (a *applicationDependencies) updateReviewHandler(w http.ResponseWriter, r *
 	}
 
 	// Update the fields if provided in the incoming JSON
-	if incomingReviewData.Author != nil {
-		review.Author = *incomingReviewData.Author
-	}
 	if incomingReviewData.Rating != nil {
 		review.Rating = *incomingReviewData.Rating
 	}
@@ -187,18 +386,41 @@ func (a *applicationDependencies) updateReviewHandler(w http.ResponseWriter, r *
 		return
 	}
 
-	// Update the review in the database
-	err = a.reviewModel.UpdateReview(review)
+	// A review changed by its author has to be screened again, a moderator's edit keeps its status
+	moderator, err := a.isModerator(r)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
 	}
+	if !moderator {
+		err = a.screenReview(review)
+		if err != nil {
+			a.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	// Update the review in the database
+	err = a.reviewModel.UpdateReview(review, a.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			a.editConflictResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Send the new ETag so the client can make its next conditional update
+	headers := make(http.Header)
+	headers.Set("ETag", resourceETag("review", review.ReviewID, review.Version))
 
 	// Send the updated review as a JSON response
 	data := envelope{
 		"review": review,
 	}
-	err = a.writeJSON(w, http.StatusOK, data, nil)
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
@@ -211,6 +433,29 @@ func (a *applicationDependencies) deleteReviewHandler(w http.ResponseWriter, r *
 		return
 	}
 
+	// Retrieve the review so we can check who owns it
+	review, err := a.reviewModel.GetReview(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.RIDnotFound(w, r, id)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Only the author of the review or a moderator may delete it
+	allowed, err := a.canModifyReview(r, review)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	if !allowed {
+		a.notPermittedResponse(w, r)
+		return
+	}
+
 	err = a.reviewModel.DeleteReview(id)
 	if err != nil {
 		switch {
@@ -225,13 +470,150 @@ func (a *applicationDependencies) deleteReviewHandler(w http.ResponseWriter, r *
 	data := envelope{
 		"message": "Review successfully deleted",
 	}
-	err = a.writeJSON(w, http.StatusOK, data, nil)
+	err = a.writeJSON(w, r, http.StatusOK, data, nil)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
 }
 
+// restoreReviewHandler takes a review back out of the trash. Like deleting, it is
+// left to the review's author and moderators
+func (a *applicationDependencies) restoreReviewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r, "rid")
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	review, err := a.reviewModel.GetDeletedReview(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.RRIDnotFound(w, r, id)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	allowed, err := a.canModifyReview(r, review)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	if !allowed {
+		a.notPermittedResponse(w, r)
+		return
+	}
+
+	err = a.reviewModel.RestoreReview(review)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.RRIDnotFound(w, r, id) // Restored or purged in the meantime
+		case errors.Is(err, data.ErrDuplicateReview):
+			// The author reviewed the product again after deleting this review
+			a.respondWithExistingReview(w, r, review.UserID, review.ProductID)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("ETag", resourceETag("review", review.ReviewID, review.Version))
+
+	data := envelope{
+		"review": review,
+	}
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// canModifyReview reports whether the authenticated user may edit or delete the review,
+// which is true for the user who wrote it and for anyone holding the moderator permission
+func (a *applicationDependencies) canModifyReview(r *http.Request, review *data.Review) (bool, error) {
+	return a.canModify(r, review.UserID)
+}
+
+// screenReview runs the review through the screener and sets its status from the score.
+// The review's current status is taken into account, so an edit can't lift a rejected or
+// hidden review past pending. Reviews that are not approved carry the screener's findings
+// as their moderation reason, and any earlier moderator decision no longer applies
+func (a *applicationDependencies) screenReview(review *data.Review) error {
+	result, err := a.reviewScreener.Screen(review)
+	if err != nil {
+		return err
+	}
+
+	policy := data.ScreeningPolicy{
+		ApproveBelow: a.config.screening.approveBelow,
+		RejectAt:     a.config.screening.rejectAt,
+	}
+	previous := review.Status
+	review.Status = policy.StatusAfterEdit(result, previous)
+	review.ModerationReason = ""
+	if review.Status != data.ReviewStatusApproved {
+		review.ModerationReason = result.Reason()
+		if review.ModerationReason == "" && review.Status != policy.Status(result) {
+			// Held back only because of the earlier decision
+			review.ModerationReason = fmt.Sprintf("edited after being %s, waiting for a moderator", previous)
+		}
+	}
+	review.ModeratedBy = 0
+	review.ModeratedAt = nil
+	return nil
+}
+
+// canViewReview reports whether the review may be shown to the user making the request.
+// Approved reviews are public; any other review only to its author and to moderators
+func (a *applicationDependencies) canViewReview(r *http.Request, review *data.Review) (bool, error) {
+	if review.Status == data.ReviewStatusApproved {
+		return true, nil
+	}
+	return a.canModify(r, review.UserID)
+}
+
+// canModify reports whether the authenticated user may change something written by the
+// user with ownerID (0 when the writer is unknown), either as its owner or as a moderator
+func (a *applicationDependencies) canModify(r *http.Request, ownerID int64) (bool, error) {
+	user := a.contextGetUser(r)
+	if ownerID != 0 && ownerID == user.ID {
+		return true, nil
+	}
+	return a.isModerator(r)
+}
+
+// isModerator reports whether the authenticated user holds the review moderation permission
+func (a *applicationDependencies) isModerator(r *http.Request) (bool, error) {
+	return a.hasPermission(r, data.PermissionReviewsModerate)
+}
+
+// hasPermission reports whether the authenticated user has been granted the permission.
+// Anonymous users have no permissions
+func (a *applicationDependencies) hasPermission(r *http.Request, code string) (bool, error) {
+	user := a.contextGetUser(r)
+	if user.IsAnonymous() {
+		return false, nil
+	}
+
+	permissions, err := a.permissionModel.GetAllPermissionsForUser(user.ID)
+	if err != nil {
+		return false, err
+	}
+	return permissions.Include(code), nil
+}
+
+// listReviewHandler lists the public, approved reviews
 func (a *applicationDependencies) listReviewHandler(w http.ResponseWriter, r *http.Request) {
+	a.listReviews(w, r, data.ReviewStatusApproved, "review_id")
+}
+
+// listReviews sends a page of the reviews in the given moderation status, filtered and
+// sorted by the query string. defaultSort is used when the client doesn't ask for a sort
+func (a *applicationDependencies) listReviews(w http.ResponseWriter, r *http.Request, status string, defaultSort string) {
 	var queryParametersData struct {
 		Author string
 		data.Filters
@@ -247,8 +629,13 @@ func (a *applicationDependencies) listReviewHandler(w http.ResponseWriter, r *ht
 	// Get pagination and sorting filters
 	queryParametersData.Filters.Page = a.getSingleIntegerParameter(queryParameters, "page", 1, v)
 	queryParametersData.Filters.PageSize = a.getSingleIntegerParameter(queryParameters, "page_size", 10, v)
-	queryParametersData.Filters.Sort = a.getSingleQueryParameter(queryParameters, "sort", "review_id")
-	queryParametersData.Filters.SortSafeList = []string{"review_id", "author", "-review_id", "-author"}
+	queryParametersData.Filters.Sort = a.getSingleQueryParameter(queryParameters, "sort", defaultSort)
+	queryParametersData.Filters.Cursor = a.getSingleQueryParameter(queryParameters, "cursor", "")
+	queryParametersData.Filters.CursorKey = []byte(a.config.cursor.secret)
+	queryParametersData.Filters.SortSafeList = []string{
+		"review_id", "author", "rating", "helpful_count", "created_at",
+		"-review_id", "-author", "-rating", "-helpful_count", "-created_at",
+	}
 
 	// Validate filters
 	data.ValidateFilters(v, queryParametersData.Filters)
@@ -260,6 +647,7 @@ func (a *applicationDependencies) listReviewHandler(w http.ResponseWriter, r *ht
 	// Fetch reviews
 	reviews, metadata, err := a.reviewModel.GetAllReviews(
 		queryParametersData.Author,
+		status,
 		queryParametersData.Filters,
 	)
 	if err != nil {
@@ -267,28 +655,30 @@ func (a *applicationDependencies) listReviewHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
+	// Add navigation links for the neighbouring pages
+	headers := a.paginationLinks(r, &metadata)
+
 	// Prepare and write response
 	responseData := envelope{
 		"Reviews":   reviews,
 		"@metadata": metadata,
 	}
-	if err := a.writeJSON(w, http.StatusOK, responseData, nil); err != nil {
+	if err := a.writeJSON(w, r, http.StatusOK, responseData, headers); err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
 }
 
+// listProductReviewHandler lists a page of the approved reviews of a product, optionally
+// narrowed down by rating and to reviews that have comments
 func (a *applicationDependencies) listProductReviewHandler(w http.ResponseWriter, r *http.Request) {
-	// Get the id from the URL /v1/comments/:id so that we
-	// can use it to query teh comments table. We will
-	// implement the readIDParam() function later
-	id, err := a.readIDParam(r, "rid")
+	id, err := a.readIDParam(r, "pid")
 	if err != nil {
 		a.notFoundResponse(w, r)
 		return
 	}
 
-	// Check if the review exists
-	exists, err := a.productModel.ProductExists(id) // Assuming you have an Exists method in reviewModel
+	// Check if the product exists
+	exists, err := a.productModel.ProductExists(id)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
@@ -298,68 +688,100 @@ func (a *applicationDependencies) listProductReviewHandler(w http.ResponseWriter
 		return
 	}
 
-	// Call Get() to retrieve the comment with the specified id
-	review, err := a.reviewModel.GetAllProductReviews(id)
+	var queryParametersData struct {
+		data.ProductReviewCriteria
+		data.Filters
+	}
+
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+
+	// Get the rating and comment filters
+	queryParametersData.Rating = a.getOptionalIntegerParameter(queryParameters, "rating", v)
+	queryParametersData.MinRating = a.getOptionalIntegerParameter(queryParameters, "min_rating", v)
+	queryParametersData.WithComments = a.getSingleBooleanParameter(queryParameters, "with_comments", false, v)
+
+	// Get pagination and sorting filters
+	queryParametersData.Filters.Page = a.getSingleIntegerParameter(queryParameters, "page", 1, v)
+	queryParametersData.Filters.PageSize = a.getSingleIntegerParameter(queryParameters, "page_size", 10, v)
+	queryParametersData.Filters.Sort = a.getSingleQueryParameter(queryParameters, "sort", "review_id")
+	queryParametersData.Filters.Cursor = a.getSingleQueryParameter(queryParameters, "cursor", "")
+	queryParametersData.Filters.CursorKey = []byte(a.config.cursor.secret)
+	queryParametersData.Filters.SortSafeList = []string{
+		"review_id", "author", "rating", "helpful_count", "created_at",
+		"-review_id", "-author", "-rating", "-helpful_count", "-created_at",
+	}
+
+	// Validate the criteria and filters
+	data.ValidateProductReviewCriteria(v, queryParametersData.ProductReviewCriteria)
+	data.ValidateFilters(v, queryParametersData.Filters)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	reviews, metadata, err := a.reviewModel.GetAllProductReviews(
+		id,
+		queryParametersData.ProductReviewCriteria,
+		queryParametersData.Filters,
+	)
 	if err != nil {
-		switch {
-		case errors.Is(err, data.ErrRecordNotFound):
-			a.notFoundResponse(w, r)
-		default:
-			a.serverErrorResponse(w, r, err)
-		}
+		a.serverErrorResponse(w, r, err)
 		return
 	}
 
-	// display the comment
+	// Add navigation links for the neighbouring pages
+	headers := a.paginationLinks(r, &metadata)
+
 	data := envelope{
-		"Review": review,
+		"reviews":   reviews,
+		"@metadata": metadata,
 	}
-	err = a.writeJSON(w, http.StatusOK, data, nil)
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
-		return
 	}
-
 }
 
-func (a *applicationDependencies) HelpfulCountHandler(w http.ResponseWriter, r *http.Request) {
-	// Read the review ID from the URL parameter
-	id, err := a.readIDParam(r, "rid")
+// reviewStatsHandler returns the rating statistics of a product's approved reviews
+func (a *applicationDependencies) reviewStatsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.readIDParam(r, "pid")
 	if err != nil {
 		a.notFoundResponse(w, r)
 		return
 	}
 
-	// Check if the review exists
-	exists, err := a.reviewModel.Exists(id) // Assuming you have an Exists method in reviewModel
+	// Check if the product exists
+	exists, err := a.productModel.ProductExists(id)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
 	}
 	if !exists {
-		a.RRIDnotFound(w, r, id)
+		a.PRIDnotFound(w, r, id)
 		return
 	}
 
-	// Retrieve and update the review's helpful count in the database
-	review, err := a.reviewModel.UpdateHelpfulCount(id)
+	stats, err := a.reviewModel.GetReviewStats(id)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
 	}
 
-	// Send the updated review as a JSON response
 	data := envelope{
-		"review": review,
+		"review_stats": stats,
 	}
-	err = a.writeJSON(w, http.StatusOK, data, nil)
+	err = a.writeJSON(w, r, http.StatusOK, data, nil)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
+}
 
-	// Log a confirmation message for the incremented helpful count
-	confirmationMessage := fmt.Sprintf("\nHelpful count incremented by 1 for the review with id = %d", id)
-	fmt.Fprintln(w, confirmationMessage)
+// HelpfulCountHandler is the original way of marking a review as helpful. It now
+// casts an "up" vote for the user, so repeating it no longer inflates the count
+func (a *applicationDependencies) HelpfulCountHandler(w http.ResponseWriter, r *http.Request) {
+	a.castVote(w, r, data.VoteUp)
 }
 
 func (a *applicationDependencies) getProductReviewHandler(w http.ResponseWriter, r *http.Request) {
@@ -389,11 +811,22 @@ func (a *applicationDependencies) getProductReviewHandler(w http.ResponseWriter,
 		return
 	}
 
+	// Reviews that aren't approved are only shown to their author and to moderators
+	visible, err := a.canViewReview(r, review)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	if !visible {
+		a.notFoundResponse(w, r)
+		return
+	}
+
 	// Send the updated review as a JSON response
 	data := envelope{
 		"review": review,
 	}
-	err = a.writeJSON(w, http.StatusOK, data, nil)
+	err = a.writeJSON(w, r, http.StatusOK, data, nil)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 	}
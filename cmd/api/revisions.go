@@ -0,0 +1,107 @@
+// Filename: cmd/api/revisions.go
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Duane-Arzu/test2/internal/data"
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// fetchRevisableReview loads the review named by the :rid URL parameter for someone reading
+// its history. Earlier versions can hold text that screening or a moderator took out, so only
+// the review's author and moderators see them. When it fails the error response has already been sent
+func (a *applicationDependencies) fetchRevisableReview(w http.ResponseWriter, r *http.Request) (*data.Review, error) {
+	review, err := a.fetchReviewByID(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := a.canModifyReview(r, review)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return nil, err
+	}
+	if !allowed {
+		a.notPermittedResponse(w, r)
+		return nil, data.ErrRecordNotFound
+	}
+	return review, nil
+}
+
+// listReviewRevisionsHandler lists the earlier versions of a review, newest first
+func (a *applicationDependencies) listReviewRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	review, err := a.fetchRevisableReview(w, r)
+	if err != nil {
+		return
+	}
+
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+
+	var filters data.Filters
+	filters.Page = a.getSingleIntegerParameter(queryParameters, "page", 1, v)
+	filters.PageSize = a.getSingleIntegerParameter(queryParameters, "page_size", 10, v)
+	filters.Sort = a.getSingleQueryParameter(queryParameters, "sort", "-version")
+	filters.SortSafeList = []string{"version", "replaced_at", "-version", "-replaced_at"}
+
+	data.ValidateFilters(v, filters)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	revisions, metadata, err := a.reviewModel.GetReviewRevisions(review.ReviewID, filters)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Add navigation links for the neighbouring pages
+	headers := a.paginationLinks(r, &metadata)
+
+	data := envelope{
+		"revisions": revisions,
+		"@metadata": metadata,
+	}
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// displayReviewRevisionHandler returns one earlier version of a review.
+// The current version is the review itself, at /v1/review/:rid
+func (a *applicationDependencies) displayReviewRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	review, err := a.fetchRevisableReview(w, r)
+	if err != nil {
+		return
+	}
+
+	version, err := a.readIDParam(r, "version")
+	if err != nil {
+		a.notFoundResponse(w, r)
+		return
+	}
+
+	revision, err := a.reviewModel.GetReviewRevision(review.ReviewID, int(version))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	data := envelope{
+		"revision": revision,
+	}
+	err = a.writeJSON(w, r, http.StatusOK, data, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
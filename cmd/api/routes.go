@@ -3,6 +3,7 @@ package main
 import (
 	"net/http"
 
+	"github.com/Duane-Arzu/test2/internal/data"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -17,22 +18,56 @@ func (a *applicationDependencies) routes() http.Handler {
 	//Product part
 	router.HandlerFunc(http.MethodGet, "/v1/healthcheck", a.healthcheckHandler)
 	router.HandlerFunc(http.MethodGet, "/v1/product", a.listProductHandler)
-	router.HandlerFunc(http.MethodPost, "/v1/product", a.createProductHandler)
+	router.HandlerFunc(http.MethodPost, "/v1/product", a.requirePermission(data.PermissionProductsWrite, a.createProductHandler))
 	router.HandlerFunc(http.MethodGet, "/v1/product/:pid", a.displayProductHandler)
-	router.HandlerFunc(http.MethodPatch, "/v1/product/:pid", a.updateProductHandler)
-	router.HandlerFunc(http.MethodDelete, "/v1/product/:pid", a.deleteProductHandler)
+	router.HandlerFunc(http.MethodPatch, "/v1/product/:pid", a.requirePermission(data.PermissionProductsWrite, a.updateProductHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/product/:pid", a.requirePermission(data.PermissionProductsWrite, a.deleteProductHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/product/:pid/restore", a.requirePermission(data.PermissionProductsWrite, a.restoreProductHandler))
 
 	// //Review part
 	router.HandlerFunc(http.MethodGet, "/v1/review", a.listReviewHandler)
-	router.HandlerFunc(http.MethodPost, "/v1/review", a.createReviewHandler)
+	router.HandlerFunc(http.MethodPost, "/v1/review", a.requirePermission(data.PermissionReviewsWrite, a.createReviewHandler))
 	router.HandlerFunc(http.MethodGet, "/v1/review/:rid", a.displayReviewHandler)
-	router.HandlerFunc(http.MethodPatch, "/v1/review/:rid", a.updateReviewHandler)
-	router.HandlerFunc(http.MethodDelete, "/v1/review/:rid", a.deleteReviewHandler)
+	router.HandlerFunc(http.MethodPatch, "/v1/review/:rid", a.requirePermission(data.PermissionReviewsWrite, a.updateReviewHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/review/:rid", a.requireActivatedUser(a.deleteReviewHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/review/:rid/restore", a.requireActivatedUser(a.restoreReviewHandler))
 
-	router.HandlerFunc(http.MethodGet, "/v1/product-review/:rid", a.listProductReviewHandler)
+	//Moderation part
+	router.HandlerFunc(http.MethodGet, "/v1/moderation/reviews", a.requirePermission(data.PermissionReviewsModerate, a.listReviewQueueHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/moderation/reviews/:rid", a.requirePermission(data.PermissionReviewsModerate, a.moderateReviewHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/moderation/reports", a.requirePermission(data.PermissionReviewsModerate, a.listReportedReviewsHandler))
+
+	//Comment part
+	router.HandlerFunc(http.MethodGet, "/v1/review/:rid/comments", a.listCommentsHandler)
+	router.HandlerFunc(http.MethodPost, "/v1/review/:rid/comments", a.requirePermission(data.PermissionReviewsWrite, a.createCommentHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/review/:rid/comments/:cid", a.displayCommentHandler)
+	router.HandlerFunc(http.MethodPatch, "/v1/review/:rid/comments/:cid", a.requirePermission(data.PermissionReviewsWrite, a.updateCommentHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/review/:rid/comments/:cid", a.requireActivatedUser(a.deleteCommentHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/product/:pid/reviews", a.listProductReviewHandler)
 	router.HandlerFunc(http.MethodGet, "/v1/product/:pid/review/:rid", a.getProductReviewHandler)
-	router.HandlerFunc(http.MethodPatch, "/v1/helpful-count/:rid", a.HelpfulCountHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/product/:pid/review-stats", a.reviewStatsHandler)
+	router.HandlerFunc(http.MethodPut, "/v1/product/:pid/my-review", a.requirePermission(data.PermissionReviewsWrite, a.myReviewHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/helpful-count/:rid", a.requireActivatedUser(a.HelpfulCountHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/review/:rid/vote", a.requireActivatedUser(a.castVoteHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/review/:rid/vote", a.requireActivatedUser(a.retractVoteHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/review/:rid/reports", a.requireActivatedUser(a.createReportHandler))
+
+	//Revision part, earlier versions of a review for its author and moderators
+	router.HandlerFunc(http.MethodGet, "/v1/review/:rid/revisions", a.requireActivatedUser(a.listReviewRevisionsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/review/:rid/revisions/:version", a.requireActivatedUser(a.displayReviewRevisionHandler))
+
+	//Audit part
+	router.HandlerFunc(http.MethodGet, "/v1/audit", a.requirePermission(data.PermissionProductsWrite, a.listAuditHandler))
+
+	//Trash part
+	router.HandlerFunc(http.MethodGet, "/v1/trash", a.requirePermission(data.PermissionProductsWrite, a.listTrashHandler))
+
+	//User part
+	router.HandlerFunc(http.MethodPost, "/v1/users", a.registerUserHandler)
+	router.HandlerFunc(http.MethodPut, "/v1/users/activated", a.activateUserHandler)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/authentication", a.createAuthenticationTokenHandler)
 
-	return a.recoverPanic(a.rateLimit(router))
+	return a.requestID(a.recoverPanic(a.rateLimit(a.authenticate(router))))
 
 }
@@ -29,6 +29,11 @@ func (a *applicationDependencies) serve() error {
 	// Create a channel to track errors during shutdown
 	shutdownError := make(chan error)
 
+	// Background jobs run until the server starts shutting down
+	jobsContext, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	a.startBackgroundJobs(jobsContext)
+
 	// Run a goroutine to handle graceful shutdown
 	go func() {
 		quit := make(chan os.Signal, 1)
@@ -37,10 +42,22 @@ func (a *applicationDependencies) serve() error {
 
 		a.logger.Info("shutting down server", "signal", s.String())
 
+		// Tell the background jobs not to start another run
+		stopJobs()
+
 		// Create a context with timeout for shutdown
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
-		shutdownError <- apiServer.Shutdown(ctx)
+		err := apiServer.Shutdown(ctx)
+		if err != nil {
+			shutdownError <- err
+			return
+		}
+
+		// Let a job that is part way through a run finish it
+		a.logger.Info("completing background jobs", "address", apiServer.Addr)
+		a.wg.Wait()
+		shutdownError <- nil
 	}()
 
 	// Start the server
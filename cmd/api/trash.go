@@ -0,0 +1,66 @@
+// Filename: cmd/api/trash.go
+package main
+
+import (
+	"net/http"
+
+	"github.com/Duane-Arzu/test2/internal/data"
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// listTrashHandler lists the deleted products and reviews that can still be restored,
+// most recently deleted first. ?type=product or ?type=review lists only one kind
+func (a *applicationDependencies) listTrashHandler(w http.ResponseWriter, r *http.Request) {
+	var queryParametersData struct {
+		Type string
+		data.Filters
+	}
+
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+
+	queryParametersData.Type = a.getSingleQueryParameter(queryParameters, "type", "")
+	queryParametersData.Filters.Page = a.getSingleIntegerParameter(queryParameters, "page", 1, v)
+	queryParametersData.Filters.PageSize = a.getSingleIntegerParameter(queryParameters, "page_size", 20, v)
+	queryParametersData.Filters.Sort = a.getSingleQueryParameter(queryParameters, "sort", "-deleted_at")
+	queryParametersData.Filters.SortSafeList = []string{"deleted_at", "type", "-deleted_at", "-type"}
+
+	data.ValidateTrashType(v, queryParametersData.Type)
+	data.ValidateFilters(v, queryParametersData.Filters)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	items, metadata, err := a.trashModel.GetTrash(queryParametersData.Type, queryParametersData.Filters)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Add navigation links for the neighbouring pages
+	headers := a.paginationLinks(r, &metadata)
+
+	data := envelope{
+		"trash":     items,
+		"@metadata": metadata,
+	}
+	err = a.writeJSON(w, r, http.StatusOK, data, headers)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// purgeTrash is the background job that deletes for good whatever has been in the
+// trash for longer than the retention period
+func (a *applicationDependencies) purgeTrash() error {
+	products, reviews, err := a.trashModel.Purge()
+	if err != nil {
+		return err
+	}
+	if products > 0 || reviews > 0 {
+		a.logger.Info("purged trash", "products", products, "reviews", reviews)
+	}
+	return nil
+}
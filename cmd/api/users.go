@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Duane-Arzu/test2/internal/data"
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// registerUserHandler handles POST requests to create a new user account
+// New accounts start out deactivated and must be activated before they can write anything
+func (a *applicationDependencies) registerUserHandler(w http.ResponseWriter, r *http.Request) {
+	// Define structure for incoming registration data
+	var incomingUserData struct {
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	// Parse JSON request body into our data structure
+	err := a.readJSON(w, r, &incomingUserData)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Create new user instance from incoming data
+	user := &data.User{
+		Name:      incomingUserData.Name,
+		Email:     incomingUserData.Email,
+		Activated: false,
+	}
+
+	// Check the plaintext password before hashing it. bcrypt refuses anything
+	// over 72 bytes, so hashing first would turn bad input into a 500
+	v := validator.New()
+	data.ValidatePasswordPlaintext(v, incomingUserData.Password)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// Hash the password before it goes anywhere near the database
+	err = user.Password.Set(incomingUserData.Password)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Validate the rest of the user data using our validation package
+	data.ValidateUser(v, user)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// Insert the validated user into the database
+	err = a.userModel.InsertUser(user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateEmail):
+			v.AddError("email", "a user with this email address already exists")
+			a.failedValidationResponse(w, r, v.Errors)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Every new user may write reviews and comments once they have activated their account
+	err = a.permissionModel.AddPermissionsForUser(user.ID, data.PermissionReviewsWrite)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Issue an activation token that is valid for 3 days
+	token, err := a.tokenModel.NewToken(user.ID, 3*24*time.Hour, data.ScopeActivation)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// The token has to reach the user out of band or activation proves nothing, so it is
+	// emailed to them. Sending can be slow, so it doesn't hold up the response
+	a.runInBackground("send activation email", func() error {
+		body := fmt.Sprintf("Hi %s,\n\nThanks for signing up. To activate your account, send a PUT request to "+
+			"/v1/users/activated with this body:\n\n{\"token\": \"%s\"}\n\nThe token expires at %s.\n",
+			user.Name, token.Plaintext, token.Expiry.Format(time.RFC1123))
+		return a.mailer.Send(user.Email, "Activate your account", body)
+	})
+
+	// Return the created user in the response
+	data := envelope{
+		"user": user,
+	}
+	err = a.writeJSON(w, r, http.StatusCreated, data, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// activateUserHandler handles PUT requests that activate an account using an activation token
+func (a *applicationDependencies) activateUserHandler(w http.ResponseWriter, r *http.Request) {
+	// Define structure for the incoming activation token
+	var incomingData struct {
+		TokenPlaintext string `json:"token"`
+	}
+
+	// Parse JSON request body into our data structure
+	err := a.readJSON(w, r, &incomingData)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Validate the token before touching the database
+	v := validator.New()
+	data.ValidateTokenPlaintext(v, incomingData.TokenPlaintext)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// Look up the user that the token was issued to
+	user, err := a.userModel.GetUserForToken(data.ScopeActivation, incomingData.TokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("token", "invalid or expired activation token")
+			a.failedValidationResponse(w, r, v.Errors)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Activate the user and save the change
+	user.Activated = true
+	err = a.userModel.UpdateUser(user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			a.editConflictResponse(w, r)
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// The activation tokens have done their job so remove them
+	err = a.tokenModel.DeleteAllTokensForUser(data.ScopeActivation, user.ID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Return the activated user in the response
+	data := envelope{
+		"user": user,
+	}
+	err = a.writeJSON(w, r, http.StatusOK, data, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,111 @@
+// Filename: cmd/api/votes.go
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Duane-Arzu/test2/internal/data"
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// castVoteHandler handles PUT requests that cast the user's vote on a review, or
+// change it if they have already voted. The body is {"vote": "up"} or {"vote": "down"}
+func (a *applicationDependencies) castVoteHandler(w http.ResponseWriter, r *http.Request) {
+	var incomingData struct {
+		Vote string `json:"vote"`
+	}
+
+	err := a.readJSON(w, r, &incomingData)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	a.castVote(w, r, incomingData.Vote)
+}
+
+// castVote records the authenticated user's vote on the review in the URL and responds
+// with the vote and the review's new counts
+func (a *applicationDependencies) castVote(w http.ResponseWriter, r *http.Request, value string) {
+	review, err := a.fetchReviewByID(w, r)
+	if err != nil {
+		return
+	}
+
+	user := a.contextGetUser(r)
+	vote := &data.ReviewVote{
+		ReviewID: review.ReviewID,
+		UserID:   user.ID,
+		Vote:     value,
+	}
+
+	v := validator.New()
+	data.ValidateReviewVote(v, vote)
+	// Authors can't vote their own reviews up (or down)
+	v.Check(review.UserID != user.ID, "vote", "must not be cast on your own review")
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	created, err := a.reviewVoteModel.CastVote(vote)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.RRIDnotFound(w, r, review.ReviewID) // The review was deleted in the meantime
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// A first vote creates a resource, a changed vote only updates it
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	data := envelope{
+		"vote": vote,
+	}
+	err = a.writeJSON(w, r, status, data, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// retractVoteHandler handles DELETE requests that take back the user's vote on a review
+func (a *applicationDependencies) retractVoteHandler(w http.ResponseWriter, r *http.Request) {
+	review, err := a.fetchReviewByID(w, r)
+	if err != nil {
+		return
+	}
+
+	vote := &data.ReviewVote{
+		ReviewID: review.ReviewID,
+		UserID:   a.contextGetUser(r).ID,
+	}
+
+	err = a.reviewVoteModel.RetractVote(vote)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			a.notFoundResponse(w, r) // The user has not voted on this review
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// Send back the review's counts without the vote that was just removed
+	data := envelope{
+		"message":         "Vote successfully retracted",
+		"helpful_count":   vote.HelpfulCount,
+		"unhelpful_count": vote.UnhelpfulCount,
+	}
+	err = a.writeJSON(w, r, http.StatusOK, data, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
@@ -7,4 +7,7 @@ require (
 	github.com/lib/pq v1.10.9
 )
 
-require golang.org/x/time v0.8.0
+require (
+	golang.org/x/crypto v0.29.0
+	golang.org/x/time v0.8.0
+)
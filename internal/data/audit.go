@@ -0,0 +1,209 @@
+// Filename: internal/data/audit.go
+package data
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// The kinds of record whose changes are audited.
+const (
+	AuditEntityProduct = "product"
+)
+
+// AuditEntities lists every entity type that appears in the audit log.
+var AuditEntities = []string{AuditEntityProduct}
+
+// The changes recorded in the audit log.
+const (
+	AuditActionInsert  = "insert"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+)
+
+// AuditActions lists every action that appears in the audit log.
+var AuditActions = []string{AuditActionInsert, AuditActionUpdate, AuditActionDelete, AuditActionRestore}
+
+// AuditActor identifies who made a change and the request it was made in.
+type AuditActor struct {
+	UserID    int64  // User making the change, 0 for changes made outside a request
+	RequestID string // ID of the HTTP request, as sent back in the X-Request-ID header
+}
+
+// AuditChange holds a field's value before and after a change. Before is null for
+// inserted and restored records and After is null for deleted ones.
+type AuditChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// AuditEntry is one change made to an audited record.
+type AuditEntry struct {
+	ID         int64                  `json:"audit_id"`
+	EntityType string                 `json:"entity_type"`          // One of AuditEntities
+	EntityID   int64                  `json:"entity_id"`            // ID of the changed record
+	Action     string                 `json:"action"`               // One of AuditActions
+	ActorID    int64                  `json:"actor_id,omitempty"`   // User who made the change, 0 if unknown or deleted
+	RequestID  string                 `json:"request_id,omitempty"` // Request the change was made in
+	Changes    map[string]AuditChange `json:"changes"`              // Only the fields whose value changed
+	CreatedAt  time.Time              `json:"created_at"`           // When the change was made
+}
+
+// AuditModel wraps the database connection pool for reading the audit log.
+type AuditModel struct {
+	DB *sql.DB
+}
+
+// auditChanges compares the fields of a record before and after a change and returns the
+// ones that differ. A nil map stands for a record that doesn't exist on that side.
+func auditChanges(before, after map[string]any) (map[string]AuditChange, error) {
+	fields := make([]string, 0, len(before)+len(after))
+	for field := range before {
+		fields = append(fields, field)
+	}
+	for field := range after {
+		if _, ok := before[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := make(map[string]AuditChange)
+	for _, field := range fields {
+		oldValue, err := json.Marshal(before[field])
+		if err != nil {
+			return nil, err
+		}
+		newValue, err := json.Marshal(after[field])
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(oldValue, newValue) {
+			changes[field] = AuditChange{Before: oldValue, After: newValue}
+		}
+	}
+	return changes, nil
+}
+
+// recordAudit writes an entry to the audit log as part of the transaction making the change,
+// so the change and its audit entry are saved or rolled back together.
+func recordAudit(ctx context.Context, tx *sql.Tx, entry *AuditEntry, actor AuditActor) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	entry.ActorID = actor.UserID
+	entry.RequestID = actor.RequestID
+
+	query := `
+		INSERT INTO audit_log (entity_type, entity_id, action, actor_id, request_id, changes)
+		VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6)
+		RETURNING audit_id, created_at
+	`
+	args := []any{entry.EntityType, entry.EntityID, entry.Action, entry.ActorID, entry.RequestID, changes}
+	return tx.QueryRowContext(ctx, query, args...).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// AuditCriteria holds the optional conditions used to narrow down the audit log.
+// Nil pointers and empty values mean "no condition".
+type AuditCriteria struct {
+	EntityType string     // Only changes to this kind of record.
+	EntityID   *int64     // Only changes to this record.
+	ActorID    *int64     // Only changes made by this user.
+	Action     string     // Only inserts, updates or deletes.
+	From       *time.Time // Only changes made at or after this time.
+	To         *time.Time // Only changes made before this time.
+}
+
+// ValidateAuditCriteria checks that the audit log conditions make sense together.
+func ValidateAuditCriteria(v *validator.Validator, c AuditCriteria) {
+	if c.EntityType != "" {
+		v.Check(validator.PermittedValue(c.EntityType, AuditEntities...), "entity", "must be one of product")
+	}
+	if c.Action != "" {
+		v.Check(validator.PermittedValue(c.Action, AuditActions...), "action", "must be one of insert, update, delete or restore")
+	}
+	if c.EntityID != nil {
+		v.Check(c.EntityType != "", "entity", "must be provided with entity_id")
+	}
+	if c.From != nil && c.To != nil {
+		v.Check(c.From.Before(*c.To), "from", "must be earlier than to")
+	}
+}
+
+// GetAllAuditEntries lists the audit log entries matching the criteria, newest first unless
+// filters asks for another order.
+func (m AuditModel) GetAllAuditEntries(criteria AuditCriteria, filters Filters) ([]*AuditEntry, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) OVER(), audit_id, entity_type, entity_id, action, COALESCE(actor_id, 0), request_id, changes, created_at
+		FROM audit_log
+		WHERE (entity_type = $1 OR $1 = '')
+		AND ($2::bigint IS NULL OR entity_id = $2)
+		AND ($3::bigint IS NULL OR actor_id = $3)
+		AND (action = $4 OR $4 = '')
+		AND ($5::timestamptz IS NULL OR created_at >= $5)
+		AND ($6::timestamptz IS NULL OR created_at < $6)
+		ORDER BY %s
+		LIMIT $7 OFFSET $8`, filters.orderBy("audit_id", false))
+
+	args := []any{
+		criteria.EntityType,
+		criteria.EntityID,
+		criteria.ActorID,
+		criteria.Action,
+		criteria.From,
+		criteria.To,
+		filters.limit(),
+		filters.offset(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	entries := []*AuditEntry{}
+	for rows.Next() {
+		var entry AuditEntry
+		var changes []byte
+		err := rows.Scan(
+			&totalRecords,
+			&entry.ID,
+			&entry.EntityType,
+			&entry.EntityID,
+			&entry.Action,
+			&entry.ActorID,
+			&entry.RequestID,
+			&changes,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		err = json.Unmarshal(changes, &entry.Changes)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		entries = append(entries, &entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetaData(totalRecords, filters.Page, filters.PageSize)
+	return entries, metadata, nil
+}
@@ -0,0 +1,112 @@
+// Filename: internal/data/audit_test.go
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuditChanges(t *testing.T) {
+	publishAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		before map[string]any
+		after  map[string]any
+		want   map[string]AuditChange
+	}{
+		{
+			name:   "nothing changed",
+			before: map[string]any{"name": "Lamp", "price": Money{Amount: 1299, Currency: "USD"}},
+			after:  map[string]any{"name": "Lamp", "price": Money{Amount: 1299, Currency: "USD"}},
+			want:   map[string]AuditChange{},
+		},
+		{
+			name:   "one field changed",
+			before: map[string]any{"name": "Lamp", "category": "lighting"},
+			after:  map[string]any{"name": "Desk lamp", "category": "lighting"},
+			want: map[string]AuditChange{
+				"name": {Before: []byte(`"Lamp"`), After: []byte(`"Desk lamp"`)},
+			},
+		},
+		{
+			name:   "created",
+			before: nil,
+			after:  map[string]any{"name": "Lamp", "status": "draft"},
+			want: map[string]AuditChange{
+				"name":   {Before: []byte(`null`), After: []byte(`"Lamp"`)},
+				"status": {Before: []byte(`null`), After: []byte(`"draft"`)},
+			},
+		},
+		{
+			name:   "deleted",
+			before: map[string]any{"name": "Lamp"},
+			after:  nil,
+			want: map[string]AuditChange{
+				"name": {Before: []byte(`"Lamp"`), After: []byte(`null`)},
+			},
+		},
+		{
+			name:   "field only on one side",
+			before: map[string]any{"name": "Lamp"},
+			after:  map[string]any{"name": "Lamp", "status": "published"},
+			want: map[string]AuditChange{
+				"status": {Before: []byte(`null`), After: []byte(`"published"`)},
+			},
+		},
+		{
+			name:   "nested value changed",
+			before: map[string]any{"price": Money{Amount: 1299, Currency: "USD"}},
+			after:  map[string]any{"price": Money{Amount: 1299, Currency: "EUR"}},
+			want: map[string]AuditChange{
+				"price": {
+					Before: []byte(`{"amount":"12.99","minor_units":1299,"currency":"USD"}`),
+					After:  []byte(`{"amount":"12.99","minor_units":1299,"currency":"EUR"}`),
+				},
+			},
+		},
+		{
+			name:   "time cleared",
+			before: map[string]any{"publish_at": &publishAt},
+			after:  map[string]any{"publish_at": (*time.Time)(nil)},
+			want: map[string]AuditChange{
+				"publish_at": {Before: []byte(`"2026-01-02T03:04:05Z"`), After: []byte(`null`)},
+			},
+		},
+		{
+			name:   "same value of different types",
+			before: map[string]any{"version": int32(3)},
+			after:  map[string]any{"version": int64(3)},
+			want:   map[string]AuditChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := auditChanges(tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("auditChanges() returned error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("auditChanges() changed %d fields, want %d: %v", len(got), len(tt.want), got)
+			}
+			for field, want := range tt.want {
+				change, ok := got[field]
+				if !ok {
+					t.Errorf("auditChanges() is missing a change to %q", field)
+					continue
+				}
+				if string(change.Before) != string(want.Before) || string(change.After) != string(want.After) {
+					t.Errorf("auditChanges()[%q] = %s -> %s, want %s -> %s", field, change.Before, change.After, want.Before, want.After)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditChangesUnmarshalableValue(t *testing.T) {
+	_, err := auditChanges(map[string]any{"bad": make(chan int)}, nil)
+	if err == nil {
+		t.Error("auditChanges() with a value that can't be marshalled returned no error")
+	}
+}
@@ -1,195 +1,224 @@
-// // file name: internal/data/comments.go
+// Filename: internal/data/comments.go
 package data
 
-// import (
-// 	"context"
-// 	"database/sql"
-// 	"errors"
-// 	"time"
-
-// 	"github.com/Duane-Arzu/comments/internal/validator"
-// 	_ "github.com/Duane-Arzu/comments/internal/validator"
-// )
-
-// // each name begins with uppercase to make them exportable/ public
-// type Comment struct {
-// 	ID        int64     `json:"id"`      //unique value per comment
-// 	Content   string    `json:"content"` //comment data
-// 	Author    string    `json:"author"`  //person who wrote comment
-// 	CreatedAt time.Time `json:"-"`       //database timestamp
-// 	Version   int32     `json:"version"` //icremented on each update
-// }
-
-// // commentModel that expects a connection pool
-// type CommentModel struct {
-// 	DB *sql.DB
-// }
-
-// // Insert Row to comments table
-// // expects a pointer to the actual comment content
-// func (c CommentModel) Insert(comment *Comment) error {
-// 	//the sql query to be executed against the database table
-// 	query := `
-// 	INSERT INTO comments (content, author)
-// 	VALUES ($1, $2)
-// 	RETURNING id, created_at, version`
-
-// 	//the actual values to be passed into $1 and $2
-// 	args := []any{comment.Content, comment.Author}
-
-// 	// Create a context with a 3-second timeout. No database
-// 	// operation should take more than 3 seconds or we will quit it
-// 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-// 	defer cancel()
-// 	// execute the query against the comments database table. We ask for the the
-// 	// id, created_at, and version to be sent back to us which we will use
-// 	// to update the Comment struct later on
-// 	return c.DB.QueryRowContext(ctx, query, args...).Scan(
-// 		&comment.ID,
-// 		&comment.CreatedAt,
-// 		&comment.Version)
-
-// }
-
-// func ValidateComment(v *validator.Validator, comment *Comment) {
-// 	//check if the content field is empty
-// 	v.Check(comment.Content != "", "content", "must be provided")
-// 	//check if the Author field is empty
-// 	v.Check(comment.Author != "", "author", "must be provided")
-// 	//check if the content field is empty
-// 	v.Check(len(comment.Content) <= 100, "content", "must not be more than 100 bytes long")
-// 	//check is author field is empty
-// 	v.Check(len(comment.Author) <= 25, "author", "must not be more than 25 bytes long")
-// }
-
-// // get a comment from DB based on ID
-// func (c CommentModel) Get(id int64) (*Comment, error) {
-// 	//check if the id is valid
-// 	if id < 1 {
-// 		return nil, ErrRecordNotFound
-// 	}
-// 	//the sql query to be excecuted against the database table
-// 	query := `
-// 	SELECT id, created_at, content, author, version
-// 	FROM comments
-// 	WHERE id = $1
-// 	`
-
-// 	//declare a variable of type Comment to hold the returned values
-// 	var comment Comment
-
-// 	//set 3-second context/timer
-// 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-// 	defer cancel()
-
-// 	err := c.DB.QueryRowContext(ctx, query, id).Scan(
-// 		&comment.ID,
-// 		&comment.Content,
-// 		&comment.Content,
-// 		&comment.Author,
-// 		&comment.Version,
-// 	)
-// 	//check for errors
-// 	if err != nil {
-// 		switch {
-// 		case errors.Is(err, sql.ErrNoRows):
-// 			return nil, ErrRecordNotFound
-// 		default:
-// 			return nil, err
-// 		}
-// 	}
-// 	return &comment, nil
-// }
-
-// func (c CommentModel) GetAll(content string, author string, filters Filters) (*[]Comment, error) {
-// 	query := `
-// 	SELECT id, created_at, content, author, version
-// 	FROM comments
-// 	WHERE (to_tsvector('simple',content) @@
-// 		plainto_tsquery('simple', $1) OR $1 = '')
-// 	AND (to_tsvector('simple',author) @@
-// 		plainto_tsquery('simple',$2) OR $2 = '')
-// 	ORDER BY id
-// 	LIMIT $3 OFFSET $4
-// 	`
-// 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-// 	defer cancel()
-
-// 	// rows, err := c.DB.QueryContext(ctx, query, content, author)
-
-// 	comments, err := c.DB.QueryContext(ctx, query, content, author, filters.limit(), filters.offset())
-// 	//check for errors
-// 	if err != nil {
-// 		switch {
-// 		case errors.Is(err, sql.ErrNoRows):
-// 			return nil, ErrRecordNotFound
-// 		default:
-// 			return nil, err
-// 		}
-// 	}
-
-// 	var cmts []Comment
-// 	for comments.Next() {
-// 		var com Comment
-// 		if err := comments.Scan(&com.ID, &com.CreatedAt, &com.Content, &com.Author, &com.Version); err != nil {
-// 			return nil, err
-// 		}
-// 		cmts = append(cmts, com)
-// 	}
-// 	return &cmts, nil
-// }
-
-// // update  a specific record from the comments table
-// func (c CommentModel) Update(comment *Comment) error {
-// 	//the sql query to be excecuted against the DB table
-// 	//Every time make an update, version number is incremented
-
-// 	query := `
-// 	UPDATE comments
-// 	SET content=$1, author=$2, version=version+1
-// 	WHERE id = $3
-// 	RETURNING version
-// 	`
-
-// 	args := []any{comment.Content, comment.Author, comment.ID}
-// 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-// 	defer cancel()
-// 	return c.DB.QueryRowContext(ctx, query, args...).Scan(&comment.Version)
-
-// }
-
-// // delete a specific comment form the comments table
-// func (c CommentModel) Delete(id int64) error {
-// 	//check if the id is valid
-// 	if id < 1 {
-// 		return ErrRecordNotFound
-// 	}
-
-// 	//sql querry to be excecuted against the database table
-// 	query := `
-// 	DELETE FROM comments
-// 	WHERE id = $1
-// 	`
-
-// 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-// 	defer cancel()
-
-// 	// ExecContext does not return any rows unlike QueryRowContext.
-// 	// It only returns  information about the the query execution
-// 	// such as how many rows were affected
-// 	result, err := c.DB.ExecContext(ctx, query, id)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	rowsAffected, err := result.RowsAffected()
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	//maybe wrong id for record was given so we sort of try checking
-// 	if rowsAffected == 0 {
-// 		return ErrRecordNotFound
-// 	}
-// 	return nil
-// }
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// MaxCommentDepth is the deepest a comment thread may be rendered in one response.
+const MaxCommentDepth = 10
+
+// Comment is a comment left on a review. Comments with a ParentID are replies,
+// so the comments on a review form a tree.
+type Comment struct {
+	ID         int64      `json:"comment_id"`                  // Unique identifier for the comment
+	ReviewID   int64      `json:"review_id"`                   // Review the comment belongs to
+	ParentID   int64      `json:"parent_comment_id,omitempty"` // Comment being replied to, 0 for top-level comments
+	UserID     int64      `json:"user_id"`                     // User who wrote the comment, 0 if they have since been deleted
+	Author     string     `json:"author"`                      // Display name of the author, taken from the user
+	Content    string     `json:"content"`                     // Comment text
+	CreatedAt  time.Time  `json:"-"`                           // Timestamp for when the comment was created
+	Version    int        `json:"version"`                     // Incremented on each update
+	ReplyCount int        `json:"reply_count"`                 // Number of direct replies, including any cut off by the depth limit
+	Replies    []*Comment `json:"replies,omitempty"`           // Nested replies, filled in by CommentTree
+}
+
+// CommentModel wraps the database connection pool for managing comments.
+type CommentModel struct {
+	DB *sql.DB
+}
+
+// ValidateComment checks the required fields and size limits of a comment.
+func ValidateComment(v *validator.Validator, comment *Comment) {
+	v.Check(comment.Content != "", "content", "must be provided")                             // Ensures content is not empty
+	v.Check(len(comment.Content) <= 1000, "content", "must not be more than 1000 bytes long") // Keeps comments short
+	v.Check(comment.Author != "", "author", "must be provided")                               // Ensures author is set
+	v.Check(len(comment.Author) <= 25, "author", "must not be more than 25 bytes long")       // Restricts author length to 25 bytes
+	v.Check(comment.ReviewID > 0, "review_id", "must be a positive integer")                  // Comment must be attached to a review
+}
+
+// InsertComment adds a new comment and fills in its ID, creation timestamp and version.
+func (c CommentModel) InsertComment(comment *Comment) error {
+	query := `
+		INSERT INTO comments (review_id, parent_comment_id, user_id, author, content)
+		VALUES ($1, NULLIF($2, 0), $3, $4, $5)
+		RETURNING comment_id, created_at, version
+	`
+	args := []any{comment.ReviewID, comment.ParentID, comment.UserID, comment.Author, comment.Content}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return c.DB.QueryRowContext(ctx, query, args...).Scan(
+		&comment.ID,
+		&comment.CreatedAt,
+		&comment.Version)
+}
+
+// GetComment retrieves a single comment by its ID. Returns ErrRecordNotFound if no comment is found.
+func (c CommentModel) GetComment(id int64) (*Comment, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+	query := `
+		SELECT comment_id, review_id, COALESCE(parent_comment_id, 0), COALESCE(user_id, 0), author, content, created_at, version
+		FROM comments
+		WHERE comment_id = $1
+	`
+	var comment Comment
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := c.DB.QueryRowContext(ctx, query, id).Scan(
+		&comment.ID,
+		&comment.ReviewID,
+		&comment.ParentID,
+		&comment.UserID,
+		&comment.Author,
+		&comment.Content,
+		&comment.CreatedAt,
+		&comment.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &comment, nil
+}
+
+// GetAllCommentsForReview returns every comment on a review, oldest first, as a flat list.
+// Use CommentTree to nest the replies under their parents.
+func (c CommentModel) GetAllCommentsForReview(reviewID int64) ([]*Comment, error) {
+	query := `
+		SELECT comment_id, review_id, COALESCE(parent_comment_id, 0), COALESCE(user_id, 0), author, content, created_at, version
+		FROM comments
+		WHERE review_id = $1
+		ORDER BY created_at ASC, comment_id ASC
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := c.DB.QueryContext(ctx, query, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*Comment
+	for rows.Next() {
+		var comment Comment
+		err := rows.Scan(
+			&comment.ID,
+			&comment.ReviewID,
+			&comment.ParentID,
+			&comment.UserID,
+			&comment.Author,
+			&comment.Content,
+			&comment.CreatedAt,
+			&comment.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, &comment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// UpdateComment changes a comment's content and increments its version. The update only
+// applies if the version is unchanged since the comment was read, otherwise ErrEditConflict is returned.
+func (c CommentModel) UpdateComment(comment *Comment) error {
+	query := `
+		UPDATE comments
+		SET content = $1, version = version + 1
+		WHERE comment_id = $2 AND version = $3
+		RETURNING version
+	`
+	args := []any{comment.Content, comment.ID, comment.Version}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := c.DB.QueryRowContext(ctx, query, args...).Scan(&comment.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict // Someone else updated (or deleted) the comment first
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteComment removes a comment together with all of its replies. Like UpdateComment
+// it only applies to the version that was read, otherwise ErrEditConflict is returned.
+func (c CommentModel) DeleteComment(comment *Comment) error {
+	query := `
+		DELETE FROM comments
+		WHERE comment_id = $1 AND version = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := c.DB.ExecContext(ctx, query, comment.ID, comment.Version)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+	return nil
+}
+
+// CommentTree nests a flat list of comments and returns the replies to parentID
+// (0 for the top-level comments). Only maxDepth levels are nested; deeper replies
+// are left out, but ReplyCount still tells the client that there is more to fetch.
+func CommentTree(comments []*Comment, parentID int64, maxDepth int) []*Comment {
+	replies := make(map[int64][]*Comment)
+	for _, comment := range comments {
+		replies[comment.ParentID] = append(replies[comment.ParentID], comment)
+	}
+	for _, comment := range comments {
+		comment.ReplyCount = len(replies[comment.ID])
+	}
+
+	var nest func(parentID int64, depth int) []*Comment
+	nest = func(parentID int64, depth int) []*Comment {
+		level := replies[parentID]
+		if depth < maxDepth {
+			for _, comment := range level {
+				comment.Replies = nest(comment.ID, depth+1)
+			}
+		}
+		return level
+	}
+
+	tree := nest(parentID, 1)
+	if tree == nil {
+		tree = []*Comment{} // Send an empty list rather than null
+	}
+	return tree
+}
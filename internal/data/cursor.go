@@ -0,0 +1,210 @@
+// Filename: internal/data/cursor.go
+package data
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// cursor is the decoded form of the opaque cursor parameter. It records the sort
+// values and ID of the row at the edge of a page, so that the next query can seek
+// straight past it instead of counting through an OFFSET.
+type cursor struct {
+	Sort     string   `json:"s"`           // Sort the cursor was issued for.
+	Values   []string `json:"v"`           // Sort values of the boundary row, one per sort field.
+	ID       int64    `json:"id"`          // ID of the boundary row, used to break ties.
+	Backward bool     `json:"b,omitempty"` // Whether to page backwards from the boundary row.
+}
+
+// cursorKey is the position of one row in the sort order.
+type cursorKey struct {
+	values []string
+	id     int64
+}
+
+// encodeCursor turns a cursor into an opaque string: the base64 JSON payload
+// followed by an HMAC signature so that clients can't forge their own.
+func (f Filters) encodeCursor(c cursor) string {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		panic("unable to encode cursor: " + err.Error()) // A struct of strings always marshals.
+	}
+
+	mac := hmac.New(sha256.New, f.CursorKey)
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// decodeCursor checks the signature on the Cursor parameter and decodes it.
+// It returns nil when the client did not send a cursor.
+func (f Filters) decodeCursor() (*cursor, error) {
+	if f.Cursor == "" {
+		return nil, nil
+	}
+
+	invalid := errors.New("must be a cursor returned by a previous request")
+	if len(f.CursorKey) == 0 {
+		return nil, invalid
+	}
+
+	payloadPart, macPart, ok := strings.Cut(f.Cursor, ".")
+	if !ok {
+		return nil, invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(payloadPart)
+	if err != nil {
+		return nil, invalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(macPart)
+	if err != nil {
+		return nil, invalid
+	}
+
+	mac := hmac.New(sha256.New, f.CursorKey)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, invalid
+	}
+
+	var c cursor
+	err = json.Unmarshal(payload, &c)
+	if err != nil {
+		return nil, invalid
+	}
+
+	// the seek values only make sense for the sort order they were taken from
+	if c.Sort != f.Sort || len(c.Values) != len(f.sortKeys()) {
+		return nil, errors.New("does not match the requested sort order")
+	}
+
+	return &c, nil
+}
+
+// seekOperator returns the comparison that selects rows after the boundary row.
+func seekOperator(direction string, backward bool) string {
+	if (direction == "ASC") != backward {
+		return ">"
+	}
+	return "<"
+}
+
+// seekPredicate returns an "AND (...)" clause keeping only the rows that come after the
+// cursor's boundary row in the sort order (or before it when paging backwards). Its
+// arguments are numbered from firstParam. For a sort of "-avg_rating,name" it expands to
+//
+//	(avg_rating < $1) OR (avg_rating = $1 AND name > $2) OR (avg_rating = $1 AND name = $2 AND id > $3)
+func (f Filters) seekPredicate(c *cursor, idColumn string, firstParam int) (string, []any) {
+	if c == nil {
+		return "", nil
+	}
+
+	keys := f.sortKeys()
+	columns := make([]string, 0, len(keys)+1)
+	operators := make([]string, 0, len(keys)+1)
+	args := make([]any, 0, len(keys)+1)
+	for i, key := range keys {
+		columns = append(columns, f.sortColumn(key))
+		operators = append(operators, seekOperator(f.sortDirection(key), c.Backward))
+		args = append(args, c.Values[i])
+	}
+	columns = append(columns, idColumn)
+	operators = append(operators, seekOperator("ASC", c.Backward))
+	args = append(args, c.ID)
+
+	alternatives := make([]string, 0, len(columns))
+	for i := range columns {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s = $%d", columns[j], firstParam+j))
+		}
+		parts = append(parts, fmt.Sprintf("%s %s $%d", columns[i], operators[i], firstParam+i))
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "AND (" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// sortSelect returns the sort expressions as extra select columns (", expr1, expr2")
+// so that the values of the first and last row can be put into cursors.
+func (f Filters) sortSelect() string {
+	var columns strings.Builder
+	for _, key := range f.sortKeys() {
+		columns.WriteString(", " + f.sortColumn(key))
+	}
+	return columns.String()
+}
+
+// sortDestinations returns scan destinations for the extra columns added by sortSelect.
+func (f Filters) sortDestinations() []any {
+	destinations := make([]any, len(f.sortKeys()))
+	for i := range destinations {
+		destinations[i] = new(any)
+	}
+	return destinations
+}
+
+// newCursorKey builds a row's position from its ID and the values scanned into sortDestinations.
+func newCursorKey(id int64, destinations []any) cursorKey {
+	values := make([]string, len(destinations))
+	for i, destination := range destinations {
+		values[i] = cursorValue(*destination.(*any))
+	}
+	return cursorKey{values: values, id: id}
+}
+
+// cursorValue converts a scanned sort value into the text form kept in a cursor.
+// The value is later sent back as a query argument and PostgreSQL casts it to the
+// column's type.
+func cursorValue(value any) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// finishPage trims the extra row fetched to detect whether there is another page,
+// restores backward pages to display order and works out the cursors for the pages
+// either side. rows and keys must be the same length.
+func finishPage[T any](f Filters, c *cursor, rows []T, keys []cursorKey) ([]T, string, string) {
+	hasMore := len(rows) > f.limit()
+	if hasMore {
+		rows = rows[:f.limit()]
+		keys = keys[:f.limit()]
+	}
+
+	backward := c != nil && c.Backward
+	if backward {
+		slices.Reverse(rows)
+		slices.Reverse(keys)
+	}
+
+	if len(rows) == 0 {
+		return rows, "", ""
+	}
+
+	var next, prev string
+	// there is a following page when more rows were found going forwards, or whenever we came back from one
+	if hasMore || backward {
+		last := keys[len(keys)-1]
+		next = f.encodeCursor(cursor{Sort: f.Sort, Values: last.values, ID: last.id})
+	}
+	// there is a preceding page when we arrived via a cursor or an OFFSET, or found more rows going backwards
+	if (backward && hasMore) || (!backward && (c != nil || f.Page > 1)) {
+		first := keys[0]
+		prev = f.encodeCursor(cursor{Sort: f.Sort, Values: first.values, ID: first.id, Backward: true})
+	}
+
+	return rows, next, prev
+}
@@ -0,0 +1,100 @@
+// Filename: internal/data/cursor_test.go
+package data
+
+import (
+	"encoding/base64"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		sort   string
+		cursor cursor
+	}{
+		{name: "single field", sort: "name", cursor: cursor{Sort: "name", Values: []string{"Lamp"}, ID: 7}},
+		{name: "several fields", sort: "-avg_rating,name", cursor: cursor{Sort: "-avg_rating,name", Values: []string{"4.5", "Lamp"}, ID: 12}},
+		{name: "backward", sort: "id", cursor: cursor{Sort: "id", Values: []string{"3"}, ID: 3, Backward: true}},
+		{name: "awkward values", sort: "name", cursor: cursor{Sort: "name", Values: []string{`a "quoted", comma.dotted name`}, ID: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Filters{Sort: tt.sort, CursorKey: []byte("secret")}
+			f.Cursor = f.encodeCursor(tt.cursor)
+
+			got, err := f.decodeCursor()
+			if err != nil {
+				t.Fatalf("decodeCursor() returned error: %v", err)
+			}
+			if got.Sort != tt.cursor.Sort || got.ID != tt.cursor.ID || got.Backward != tt.cursor.Backward ||
+				!slices.Equal(got.Values, tt.cursor.Values) {
+				t.Errorf("decodeCursor() = %+v, want %+v", *got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	key := []byte("secret")
+	signer := Filters{Sort: "name", CursorKey: key}
+	valid := signer.encodeCursor(cursor{Sort: "name", Values: []string{"Lamp"}, ID: 7})
+	payloadPart, macPart, _ := strings.Cut(valid, ".")
+
+	// a payload the client has edited, still carrying the original signature
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"name","v":["Lamp"],"id":1}`)) + "." + macPart
+
+	// a payload signed with another key
+	otherSigner := Filters{Sort: "name", CursorKey: []byte("another secret")}
+	otherKey := otherSigner.encodeCursor(cursor{Sort: "name", Values: []string{"Lamp"}, ID: 7})
+
+	// a payload with one byte of its signature changed
+	signature, _ := base64.RawURLEncoding.DecodeString(macPart)
+	signature[0] ^= 0xff
+	flipped := payloadPart + "." + base64.RawURLEncoding.EncodeToString(signature)
+
+	tests := []struct {
+		name    string
+		cursor  string
+		sort    string
+		key     []byte
+		wantErr bool
+	}{
+		{name: "valid", cursor: valid, sort: "name", key: key},
+		{name: "forged payload", cursor: forged, sort: "name", key: key, wantErr: true},
+		{name: "signed with another key", cursor: otherKey, sort: "name", key: key, wantErr: true},
+		{name: "changed signature", cursor: flipped, sort: "name", key: key, wantErr: true},
+		{name: "missing signature", cursor: payloadPart, sort: "name", key: key, wantErr: true},
+		{name: "empty signature", cursor: payloadPart + ".", sort: "name", key: key, wantErr: true},
+		{name: "not base64", cursor: "!!!.???", sort: "name", key: key, wantErr: true},
+		{name: "no key configured", cursor: valid, sort: "name", wantErr: true},
+		{name: "different sort", cursor: valid, sort: "-name", key: key, wantErr: true},
+		{name: "more sort fields", cursor: valid, sort: "name,price", key: key, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Filters{Sort: tt.sort, Cursor: tt.cursor, CursorKey: tt.key}
+			got, err := f.decodeCursor()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeCursor() = %+v, want an error", *got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeCursor() returned error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorWithoutCursor(t *testing.T) {
+	f := Filters{Sort: "name", CursorKey: []byte("secret")}
+	got, err := f.decodeCursor()
+	if err != nil || got != nil {
+		t.Errorf("decodeCursor() = %v, %v, want nil, nil", got, err)
+	}
+}
@@ -2,6 +2,7 @@
 package data
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/Duane-Arzu/test2/internal/validator"
@@ -11,63 +12,137 @@ import (
 // The Filters struct holds pagination and sorting parameters
 // that help in managing paginated results for client requests.
 type Filters struct {
-	Page         int      // Specifies the page number requested by the client.
-	PageSize     int      // Specifies the number of records per page.
-	Sort         string   // Field by which to sort the results, with optional direction.
-	SortSafeList []string // List of allowed fields for sorting to prevent unsafe queries.
+	Page         int               // Specifies the page number requested by the client.
+	PageSize     int               // Specifies the number of records per page.
+	Sort         string            // Comma-separated fields by which to sort the results, each with optional direction.
+	SortSafeList []string          // List of allowed fields for sorting to prevent unsafe queries.
+	SortColumns  map[string]string // Maps sort fields to SQL expressions where the two differ; expressions must never be NULL.
+	Cursor       string            // Opaque cursor from a previous page; when set it replaces Page.
+	CursorKey    []byte            // Secret used to sign and verify cursors.
 }
 
+// maxSortKeys limits how many fields a client may sort by at once.
+const maxSortKeys = 3
+
+// MaxPage is the highest page number a client may ask for; past it they page by cursor.
+const MaxPage = 500
+
 // The Metadata struct contains pagination details
 // that will be sent back to the client.
 type Metadata struct {
-	CurrentPage  int `json:"current_page,omitempty"`  // Indicates the current page in the paginated results.
-	PageSize     int `json:"page_size,omitempty"`     // Specifies the number of items per page.
-	FirstPage    int `json:"first_page,omitempty"`    // The first page in the dataset (usually 1).
-	LastPage     int `json:"last_page,omitempty"`     // The last available page based on total records.
-	TotalRecords int `json:"total_records,omitempty"` // The total count of records across all pages.
+	CurrentPage  int    `json:"current_page,omitempty"`  // Indicates the current page in the paginated results.
+	PageSize     int    `json:"page_size,omitempty"`     // Specifies the number of items per page.
+	FirstPage    int    `json:"first_page,omitempty"`    // The first page in the dataset (usually 1).
+	LastPage     int    `json:"last_page,omitempty"`     // The last available page based on total records.
+	TotalRecords int    `json:"total_records,omitempty"` // The total count of records across all pages.
+	NextCursor   string `json:"next_cursor,omitempty"`   // Cursor for the page after this one, if there is one.
+	PrevCursor   string `json:"prev_cursor,omitempty"`   // Cursor for the page before this one, if there is one.
+	FirstURL     string `json:"first_url,omitempty"`     // Absolute URL of the first page.
+	PrevURL      string `json:"prev_url,omitempty"`      // Absolute URL of the previous page, if there is one.
+	NextURL      string `json:"next_url,omitempty"`      // Absolute URL of the next page, if there is one.
+	LastURL      string `json:"last_url,omitempty"`      // Absolute URL of the last page, when it is known.
 }
 
 // ValidateFilters checks that the pagination and sorting parameters
 // in Filters struct are valid and within acceptable ranges.
 func ValidateFilters(v *validator.Validator, f Filters) {
-	v.Check(f.Page > 0, "page", "must be greater than zero")             // Ensure page number is positive.
-	v.Check(f.Page <= 500, "page", "must be a maximum of 500")           // Limit page number to a maximum of 500.
-	v.Check(f.PageSize > 0, "page_size", "must be greater than zero")    // Ensure page size is positive.
-	v.Check(f.PageSize <= 100, "page_size", "must be a maximum of 100")  // Limit page size to a maximum of 100 records.
-	v.Check(validator.PermittedValue(f.Sort, f.SortSafeList...), "sort", // Validate sort field is allowed.
-		"invalid sort value")
+	v.Check(f.Page > 0, "page", "must be greater than zero")            // Ensure page number is positive.
+	v.Check(f.Page <= MaxPage, "page", "must be a maximum of 500")      // Limit page number to a maximum of 500.
+	v.Check(f.PageSize > 0, "page_size", "must be greater than zero")   // Ensure page size is positive.
+	v.Check(f.PageSize <= 100, "page_size", "must be a maximum of 100") // Limit page size to a maximum of 100 records.
+	keys := f.sortKeys()
+	v.Check(len(keys) <= maxSortKeys, "sort", fmt.Sprintf("must not contain more than %d fields", maxSortKeys)) // Limit the number of sort fields.
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		v.Check(validator.PermittedValue(key, f.SortSafeList...), "sort", // Validate each sort field is allowed.
+			"invalid sort value")
+		column := strings.TrimPrefix(key, "-")
+		v.Check(!seen[column], "sort", "must not contain the same field twice") // Each field may only appear once.
+		seen[column] = true
+	}
+	if v.IsEmpty() {
+		_, err := f.decodeCursor() // Only trust cursors we signed for this sort order.
+		if err != nil {
+			v.AddError("cursor", err.Error())
+		}
+	}
+}
+
+// sortKeys splits the comma-separated Sort value into its individual fields.
+func (f Filters) sortKeys() []string {
+	keys := strings.Split(f.Sort, ",")
+	for i := range keys {
+		keys[i] = strings.TrimSpace(keys[i])
+	}
+	return keys
 }
 
-// sortColumn returns the sanitized sort field by removing any
+// sortColumn returns the sanitized SQL column for a sort field by removing any
 // direction indicator (like '-') to prevent SQL injection risks.
-func (f Filters) sortColumn() string {
+func (f Filters) sortColumn(key string) string {
 	for _, safeValue := range f.SortSafeList {
-		if f.Sort == safeValue {
-			return strings.TrimPrefix(f.Sort, "-") // Remove prefix for consistency.
+		if key == safeValue {
+			column := strings.TrimPrefix(key, "-") // Remove prefix for consistency.
+			if mapped, ok := f.SortColumns[column]; ok {
+				return mapped // Use the SQL column when it is named differently.
+			}
+			return column
 		}
 	}
 	// Prevent operation if unsafe sort parameter detected,
 	// which could be used for SQL injection.
-	panic("unsafe sort parameter: " + f.Sort)
+	panic("unsafe sort parameter: " + key)
 }
 
-// sortDirection determines the direction of sorting
+// sortDirection determines the direction of sorting for a sort field
 // (ASC for ascending, DESC for descending) based on the prefix.
-func (f Filters) sortDirection() string {
-	if strings.HasPrefix(f.Sort, "-") {
+func (f Filters) sortDirection(key string) string {
+	if strings.HasPrefix(key, "-") {
 		return "DESC" // Indicates descending order.
 	}
 	return "ASC" // Default to ascending order.
 }
 
+// orderBy builds the ORDER BY list for every sort field followed by the ID column as a tie-breaker,
+// e.g. "avg_rating DESC NULLS LAST, name ASC NULLS LAST, product_id ASC". Rows with no value always
+// come last so that they don't crowd the top of a descending sort. Paging backwards flips every direction.
+func (f Filters) orderBy(idColumn string, backward bool) string {
+	keys := f.sortKeys()
+	clauses := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		direction := f.sortDirection(key)
+		if backward && direction == "ASC" {
+			direction = "DESC"
+		} else if backward {
+			direction = "ASC"
+		}
+		clauses = append(clauses, fmt.Sprintf("%s %s NULLS LAST", f.sortColumn(key), direction))
+	}
+	if backward {
+		clauses = append(clauses, idColumn+" DESC")
+	} else {
+		clauses = append(clauses, idColumn+" ASC")
+	}
+	return strings.Join(clauses, ", ")
+}
+
 // limit returns the page size, representing the number of records per page.
 func (f Filters) limit() int {
 	return f.PageSize
 }
 
+// fetchLimit returns one more than the page size, the extra row telling us whether another page follows.
+func (f Filters) fetchLimit() int {
+	return f.PageSize + 1
+}
+
 // offset calculates the starting position of records to skip,
 // based on the current page, for pagination purposes.
+// A cursor already points at the right place so nothing is skipped.
 func (f Filters) offset() int {
+	if f.Cursor != "" {
+		return 0
+	}
 	return (f.Page - 1) * f.PageSize
 }
 
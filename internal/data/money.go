@@ -0,0 +1,197 @@
+// Filename: internal/data/money.go
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when a client sends a price without a currency.
+const DefaultCurrency = "USD"
+
+// currencyExponents maps active ISO 4217 currency codes to the number of
+// decimal places in their minor unit (2 for cents, 0 for yen, 3 for fils).
+var currencyExponents = map[string]int{
+	"AED": 2, "AFN": 2, "ALL": 2, "AMD": 2, "ANG": 2, "AOA": 2, "ARS": 2, "AUD": 2, "AWG": 2, "AZN": 2,
+	"BAM": 2, "BBD": 2, "BDT": 2, "BGN": 2, "BHD": 3, "BIF": 0, "BMD": 2, "BND": 2, "BOB": 2, "BRL": 2,
+	"BSD": 2, "BTN": 2, "BWP": 2, "BYN": 2, "BZD": 2, "CAD": 2, "CDF": 2, "CHF": 2, "CLP": 0, "CNY": 2,
+	"COP": 2, "CRC": 2, "CUP": 2, "CVE": 2, "CZK": 2, "DJF": 0, "DKK": 2, "DOP": 2, "DZD": 2, "EGP": 2,
+	"ERN": 2, "ETB": 2, "EUR": 2, "FJD": 2, "FKP": 2, "GBP": 2, "GEL": 2, "GHS": 2, "GIP": 2, "GMD": 2,
+	"GNF": 0, "GTQ": 2, "GYD": 2, "HKD": 2, "HNL": 2, "HTG": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2,
+	"IQD": 3, "IRR": 2, "ISK": 0, "JMD": 2, "JOD": 3, "JPY": 0, "KES": 2, "KGS": 2, "KHR": 2, "KMF": 0,
+	"KPW": 2, "KRW": 0, "KWD": 3, "KYD": 2, "KZT": 2, "LAK": 2, "LBP": 2, "LKR": 2, "LRD": 2, "LSL": 2,
+	"LYD": 3, "MAD": 2, "MDL": 2, "MGA": 2, "MKD": 2, "MMK": 2, "MNT": 2, "MOP": 2, "MRU": 2, "MUR": 2,
+	"MVR": 2, "MWK": 2, "MXN": 2, "MYR": 2, "MZN": 2, "NAD": 2, "NGN": 2, "NIO": 2, "NOK": 2, "NPR": 2,
+	"NZD": 2, "OMR": 3, "PAB": 2, "PEN": 2, "PGK": 2, "PHP": 2, "PKR": 2, "PLN": 2, "PYG": 0, "QAR": 2,
+	"RON": 2, "RSD": 2, "RUB": 2, "RWF": 0, "SAR": 2, "SBD": 2, "SCR": 2, "SDG": 2, "SEK": 2, "SGD": 2,
+	"SHP": 2, "SLE": 2, "SOS": 2, "SRD": 2, "SSP": 2, "STN": 2, "SVC": 2, "SYP": 2, "SZL": 2, "THB": 2,
+	"TJS": 2, "TMT": 2, "TND": 3, "TOP": 2, "TRY": 2, "TTD": 2, "TWD": 2, "TZS": 2, "UAH": 2, "UGX": 0,
+	"USD": 2, "UYU": 2, "UZS": 2, "VES": 2, "VND": 0, "VUV": 0, "WST": 2, "XAF": 0, "XCD": 2, "XOF": 0,
+	"XPF": 0, "YER": 2, "ZAR": 2, "ZMW": 2, "ZWL": 2,
+}
+
+// ValidCurrency reports whether the code is an active ISO 4217 currency code.
+func ValidCurrency(code string) bool {
+	_, ok := currencyExponents[code]
+	return ok
+}
+
+// Money is an amount held in the currency's minor units (e.g. cents) so that
+// prices can be compared, sorted and summed without floating point errors.
+type Money struct {
+	Amount   int64  // Amount in minor units.
+	Currency string // ISO 4217 currency code.
+	raw      string // Decimal amount as sent by the client, waiting for Resolve() once the currency is known.
+}
+
+// ParseMoney converts a decimal string such as "12.99" into Money for the currency.
+func ParseMoney(amount string, currency string) (Money, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return Money{}, fmt.Errorf("unknown currency %q", currency)
+	}
+
+	amount = strings.TrimSpace(amount)
+	whole, fraction, _ := strings.Cut(amount, ".")
+	if whole == "" || strings.HasPrefix(whole, "-") || strings.HasPrefix(whole, "+") {
+		return Money{}, errors.New("must be a non-negative decimal amount")
+	}
+	// zeros past the minor unit don't change the amount, so "12.00" is fine for yen
+	if len(fraction) > exponent {
+		fraction = fraction[:exponent] + strings.TrimRight(fraction[exponent:], "0")
+	}
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("must not have more than %d decimal places for %s", exponent, currency)
+	}
+
+	// pad the fraction out to the full number of minor unit digits, "12.5" -> "1250"
+	digits := whole + fraction + strings.Repeat("0", exponent-len(fraction))
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, errors.New("must be a non-negative decimal amount")
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// String formats the amount as a decimal in major units, e.g. "12.99".
+func (m Money) String() string {
+	if m.raw != "" {
+		return m.raw
+	}
+
+	exponent := currencyExponents[m.Currency]
+	if exponent == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	divisor := int64(1)
+	for i := 0; i < exponent; i++ {
+		divisor *= 10
+	}
+	return fmt.Sprintf("%d.%0*d", m.Amount/divisor, exponent, m.Amount%divisor)
+}
+
+// Resolve converts an amount read from the client into minor units. It must be
+// called after the currency has been set, since that decides the decimal places.
+func (m *Money) Resolve() error {
+	if m.raw == "" {
+		return nil
+	}
+
+	parsed, err := ParseMoney(m.raw, m.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+// MarshalJSON emits money as a structured object, e.g.
+// {"amount": "12.99", "minor_units": 1299, "currency": "USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	output := struct {
+		Amount     string `json:"amount"`
+		MinorUnits *int64 `json:"minor_units,omitempty"`
+		Currency   string `json:"currency"`
+	}{
+		Amount:   m.String(),
+		Currency: m.Currency,
+	}
+
+	// unresolved amounts (e.g. legacy rows) have no trustworthy minor units
+	if m.raw == "" {
+		output.MinorUnits = &m.Amount
+	}
+
+	return json.Marshal(output)
+}
+
+// UnmarshalJSON accepts either a bare amount ("12.99" or 12.99) or an object
+// such as {"amount": "12.99", "currency": "EUR"}. The amount is kept as text
+// until Resolve() is called because its precision depends on the currency.
+func (m *Money) UnmarshalJSON(jsonValue []byte) error {
+	jsonValue = bytes.TrimSpace(jsonValue)
+
+	if len(jsonValue) > 0 && jsonValue[0] == '{' {
+		var input struct {
+			Amount   json.Number `json:"amount"`
+			Currency string      `json:"currency"`
+		}
+		dec := json.NewDecoder(bytes.NewReader(jsonValue))
+		dec.UseNumber()
+		dec.DisallowUnknownFields()
+		err := dec.Decode(&input)
+		if err != nil {
+			return errors.New("price must be an amount or an object with amount and currency")
+		}
+		*m = Money{Currency: input.Currency, raw: input.Amount.String()}
+		return nil
+	}
+
+	amount, err := strconv.Unquote(string(jsonValue))
+	if err != nil {
+		// not a string, so accept a bare JSON number as is
+		amount = string(jsonValue)
+	}
+	if _, err := strconv.ParseFloat(amount, 64); err != nil {
+		return errors.New("price must be a decimal amount such as \"12.99\"")
+	}
+
+	*m = Money{raw: amount}
+	return nil
+}
+
+// InCurrency returns the same decimal amount in another currency, e.g. "12.99" USD
+// becomes "12.99" EUR. Call Resolve() on the result to convert it into minor units.
+func (m Money) InCurrency(currency string) Money {
+	return Money{Currency: currency, raw: m.String()}
+}
+
+// IsResolved reports whether the amount has been converted into minor units.
+func (m Money) IsResolved() bool {
+	return m.raw == ""
+}
+
+// legacyMoney interprets a price stored in the old free-text price column. Currency
+// symbols and thousands separators are stripped; anything still unreadable is kept
+// as text so that it is shown to clients rather than silently becoming zero.
+func legacyMoney(text string, currency string) Money {
+	cleaned := strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '.' {
+			return r
+		}
+		return -1
+	}, text)
+
+	money, err := ParseMoney(cleaned, currency)
+	if err != nil {
+		return Money{Currency: currency, raw: text}
+	}
+	return money
+}
@@ -0,0 +1,153 @@
+// Filename: internal/data/money_test.go
+package data
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{name: "two decimals", amount: "12.99", currency: "USD", want: 1299},
+		{name: "two decimals padded", amount: "12.5", currency: "EUR", want: 1250},
+		{name: "two decimals whole amount", amount: "12", currency: "GBP", want: 1200},
+		{name: "two decimals too precise", amount: "12.999", currency: "USD", wantErr: true},
+		{name: "two decimals trailing zero", amount: "12.990", currency: "USD", want: 1299},
+		{name: "zero decimals", amount: "1500", currency: "JPY", want: 1500},
+		{name: "zero decimals with fraction", amount: "1500.5", currency: "JPY", wantErr: true},
+		{name: "zero decimals with zero fraction", amount: "1500.00", currency: "JPY", want: 1500},
+		{name: "three decimals", amount: "1.234", currency: "BHD", want: 1234},
+		{name: "three decimals padded", amount: "1.2", currency: "KWD", want: 1200},
+		{name: "three decimals too precise", amount: "1.2345", currency: "KWD", wantErr: true},
+		{name: "zero", amount: "0", currency: "USD", want: 0},
+		{name: "surrounding spaces", amount: " 3.10 ", currency: "USD", want: 310},
+		{name: "negative", amount: "-1.00", currency: "USD", wantErr: true},
+		{name: "explicit plus", amount: "+1.00", currency: "USD", wantErr: true},
+		{name: "no whole part", amount: ".50", currency: "USD", wantErr: true},
+		{name: "not a number", amount: "twelve", currency: "USD", wantErr: true},
+		{name: "unknown currency", amount: "1.00", currency: "XYZ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.amount, tt.currency)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMoney(%q, %q) = %d, want an error", tt.amount, tt.currency, got.Amount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q, %q) returned error: %v", tt.amount, tt.currency, err)
+			}
+			if got.Amount != tt.want || got.Currency != tt.currency {
+				t.Errorf("ParseMoney(%q, %q) = %d %s, want %d %s", tt.amount, tt.currency, got.Amount, got.Currency, tt.want, tt.currency)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		name  string
+		money Money
+		want  string
+	}{
+		{name: "two decimals", money: Money{Amount: 1299, Currency: "USD"}, want: "12.99"},
+		{name: "two decimals leading zero", money: Money{Amount: 5, Currency: "USD"}, want: "0.05"},
+		{name: "zero decimals", money: Money{Amount: 1500, Currency: "JPY"}, want: "1500"},
+		{name: "three decimals", money: Money{Amount: 1234, Currency: "BHD"}, want: "1.234"},
+		{name: "three decimals leading zeros", money: Money{Amount: 7, Currency: "KWD"}, want: "0.007"},
+		{name: "unresolved amount", money: Money{Currency: "USD", raw: "about 10"}, want: "about 10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.money.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyRoundTrip(t *testing.T) {
+	for _, currency := range []string{"USD", "JPY", "BHD"} {
+		for _, amount := range []int64{0, 1, 99, 100, 123456} {
+			money := Money{Amount: amount, Currency: currency}
+			parsed, err := ParseMoney(money.String(), currency)
+			if err != nil {
+				t.Fatalf("ParseMoney(%q, %q) returned error: %v", money.String(), currency, err)
+			}
+			if parsed.Amount != amount {
+				t.Errorf("%d %s came back as %d after formatting as %q", amount, currency, parsed.Amount, money.String())
+			}
+		}
+	}
+}
+
+func TestMoneyInCurrency(t *testing.T) {
+	tests := []struct {
+		name     string
+		money    Money
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{name: "same exponent", money: Money{Amount: 1299, Currency: "USD"}, currency: "EUR", want: 1299},
+		{name: "to zero decimals", money: Money{Amount: 1200, Currency: "USD"}, currency: "JPY", want: 12},
+		{name: "to zero decimals with cents", money: Money{Amount: 1299, Currency: "USD"}, currency: "JPY", wantErr: true},
+		{name: "to three decimals", money: Money{Amount: 1299, Currency: "USD"}, currency: "KWD", want: 12990},
+		{name: "from zero decimals", money: Money{Amount: 1500, Currency: "JPY"}, currency: "USD", want: 150000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.money.InCurrency(tt.currency)
+			err := got.Resolve()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("InCurrency(%q) resolved to %d, want an error", tt.currency, got.Amount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InCurrency(%q) failed to resolve: %v", tt.currency, err)
+			}
+			if got.Amount != tt.want || got.Currency != tt.currency {
+				t.Errorf("InCurrency(%q) = %d %s, want %d %s", tt.currency, got.Amount, got.Currency, tt.want, tt.currency)
+			}
+		})
+	}
+}
+
+func TestLegacyMoney(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		wantAmount   int64
+		wantResolved bool
+	}{
+		{name: "plain amount", text: "12.99", wantAmount: 1299, wantResolved: true},
+		{name: "currency symbol", text: "$12.99", wantAmount: 1299, wantResolved: true},
+		{name: "thousands separator", text: "1,299.00", wantAmount: 129900, wantResolved: true},
+		{name: "unreadable", text: "call for price", wantResolved: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := legacyMoney(tt.text, "USD")
+			if got.IsResolved() != tt.wantResolved {
+				t.Fatalf("legacyMoney(%q) resolved = %t, want %t", tt.text, got.IsResolved(), tt.wantResolved)
+			}
+			if tt.wantResolved && got.Amount != tt.wantAmount {
+				t.Errorf("legacyMoney(%q) = %d, want %d", tt.text, got.Amount, tt.wantAmount)
+			}
+			if !tt.wantResolved && got.String() != tt.text {
+				t.Errorf("legacyMoney(%q) shows as %q, want the original text", tt.text, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Filename: internal/data/permissions.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// The permission codes checked by the API.
+const (
+	PermissionProductsWrite   = "products:write"
+	PermissionReviewsModerate = "reviews:moderate"
+	PermissionReviewsWrite    = "reviews:write"
+)
+
+// Permissions holds the permission codes granted to a single user.
+type Permissions []string
+
+// Include reports whether the code is in the slice of permissions.
+func (p Permissions) Include(code string) bool {
+	return slices.Contains(p, code)
+}
+
+// PermissionModel provides methods for interacting with the permissions tables.
+type PermissionModel struct {
+	DB *sql.DB // Database connection pool.
+}
+
+// GetAllPermissionsForUser returns every permission code granted to the user.
+func (p PermissionModel) GetAllPermissionsForUser(userID int64) (Permissions, error) {
+	query := `
+		SELECT permissions.code
+		FROM permissions
+		INNER JOIN users_permissions ON users_permissions.permission_id = permissions.permission_id
+		WHERE users_permissions.user_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := p.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions Permissions
+	for rows.Next() {
+		var permission string
+		err := rows.Scan(&permission)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// AddPermissionsForUser grants the given permission codes to the user.
+func (p PermissionModel) AddPermissionsForUser(userID int64, codes ...string) error {
+	query := `
+		INSERT INTO users_permissions (user_id, permission_id)
+		SELECT $1, permissions.permission_id FROM permissions WHERE permissions.code = ANY($2)
+		ON CONFLICT DO NOTHING
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := p.DB.ExecContext(ctx, query, userID, pq.Array(codes))
+	return err
+}
@@ -6,28 +6,56 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/Duane-Arzu/test2/internal/validator"
+	"github.com/lib/pq"
 )
 
 // Product represents the data structure for a product entity in the application,
 // holding information about the product's identification, details, and metadata.
 type Product struct {
-	ProductID   int64     `json:"product_id"`  // Unique identifier for each product.
-	Name        string    `json:"name"`        // Product name.
-	Description string    `json:"description"` // Brief description of the product.
-	Category    string    `json:"category"`    // Category the product belongs to.
-	ImageURL    string    `json:"image_url"`   // URL link to the product image.
-	Price       string    `json:"price"`       // Price of the product.
-	AvgRating   float32   `json:"avg_rating"`  // Average rating from reviews, if available.
-	CreatedAt   time.Time `json:"created_at"`  // Timestamp for when the product was created (not exposed in JSON).
-	Version     int32     `json:"version"`     // Version for optimistic locking during updates.
+	ProductID   int64      `json:"product_id"`           // Unique identifier for each product.
+	Name        string     `json:"name"`                 // Product name.
+	Description string     `json:"description"`          // Brief description of the product.
+	Category    string     `json:"category"`             // Category the product belongs to.
+	ImageURL    string     `json:"image_url"`            // URL link to the product image.
+	Price       Money      `json:"price"`                // Price of the product in minor units plus currency.
+	AvgRating   float32    `json:"avg_rating"`           // Average rating from reviews, if available.
+	ReviewCount int64      `json:"review_count"`         // Number of approved reviews written for the product, maintained by a trigger.
+	Score       float64    `json:"score"`                // Bayesian average rating, used to rank products with few reviews fairly.
+	Status      string     `json:"status"`               // Lifecycle state, only published products are shown to the public.
+	PublishAt   *time.Time `json:"publish_at,omitempty"` // When a draft is due to be published by the scheduler.
+	CreatedAt   time.Time  `json:"created_at"`           // Timestamp for when the product was created (not exposed in JSON).
+	Version     int32      `json:"version"`              // Version for optimistic locking during updates.
 }
 
+// The lifecycle states of a product. New products are drafts until they are published,
+// and a published product can later be archived to take it off sale.
+const (
+	ProductStatusDraft     = "draft"
+	ProductStatusPublished = "published"
+	ProductStatusArchived  = "archived"
+)
+
+// ProductStatuses lists every lifecycle state, in the order they are usually reached.
+var ProductStatuses = []string{ProductStatusDraft, ProductStatusPublished, ProductStatusArchived}
+
 // ProductModel provides methods for interacting with the products database table.
 type ProductModel struct {
-	DB *sql.DB // Database connection pool.
+	DB          *sql.DB // Database connection pool.
+	PriorMean   float64 // Rating a product is assumed to have before it has any reviews.
+	PriorWeight float64 // How many reviews the prior mean counts as when computing Score.
+}
+
+// scoreColumn returns the SQL expression for a product's Bayesian average: its ratings plus
+// PriorWeight imaginary reviews of PriorMean stars, averaged. A single 5-star review barely
+// moves a product away from the prior, while hundreds of reviews outweigh it.
+func (p ProductModel) scoreColumn() string {
+	prior := strconv.FormatFloat(p.PriorMean*p.PriorWeight, 'f', -1, 64)
+	weight := strconv.FormatFloat(p.PriorWeight, 'f', -1, 64)
+	return fmt.Sprintf("COALESCE(ROUND((rating_sum + %s) / NULLIF(review_count + %s, 0), 4), 0)::float8", prior, weight)
 }
 
 // ValidateProduct checks if the fields in the Product struct adhere to specified validation rules.
@@ -39,27 +67,125 @@ func ValidateProduct(v *validator.Validator, product *Product) {
 	v.Check(product.Category != "", "category", "must be provided")                                      // Category must be provided.
 	v.Check(product.ImageURL != "", "image_url", "must be provided")                                     // Ensure an image URL is given.
 	v.Check(len(product.ImageURL) <= 255, "image_url", "must not be more than 255 characters long")      // Limit image URL length.
-	v.Check(len(product.Price) <= 10, "price", "must not be more than 10 characters long")               // Limit price field length.
+	v.Check(ValidCurrency(product.Price.Currency), "currency", "must be a valid ISO 4217 currency code") // Currency must be a known ISO 4217 code.
+	v.Check(product.Price.IsResolved(), "price", "must be a valid amount for the currency")              // Price must be convertible to minor units.
+	v.Check(product.Price.Amount >= 0, "price", "must not be negative")                                  // Prices can't be negative.
+	v.Check(validator.PermittedValue(product.Status, ProductStatuses...), "status", "must be one of draft, published or archived")
 	// v.Check(product.AverageRating >= 0 && product.AverageRating <= 5, "avg_rating", "must be between 0 and 5") // Ensure rating is within valid range.
 }
 
 // InsertProduct inserts a new product into the database, returning the product's unique ID, creation time, and version.
-func (p ProductModel) InsertProduct(product *Product) error {
+// The new product is recorded in the audit log, in the same transaction, as made by actor.
+func (p ProductModel) InsertProduct(product *Product, actor AuditActor) error {
 	query := `
-		INSERT INTO products (name, description, category, image_url, price)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO products (name, description, category, image_url, price_amount, currency, price, status, publish_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING product_id, created_at, version
 	`
-	args := []any{product.Name, product.Description, product.Category, product.ImageURL, product.Price}
+	// The legacy price text column is still written so that older readers keep working during the migration.
+	args := []any{product.Name, product.Description, product.Category, product.ImageURL, product.Price.Amount, product.Price.Currency, product.Price.String(), product.Status, product.PublishAt}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	return p.DB.QueryRowContext(ctx, query, args...).Scan(
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Has no effect once the transaction is committed.
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(
 		&product.ProductID,
 		&product.CreatedAt,
 		&product.Version,
 	)
+	if err != nil {
+		return err
+	}
+
+	err = p.auditProduct(ctx, tx, AuditActionInsert, nil, product, actor)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockProduct reads the fields of a product that the audit log tracks and locks its row
+// for the rest of the transaction. deleted picks whether the product is looked for in the
+// trash or outside it. Returns ErrRecordNotFound if there is no such product.
+func lockProduct(ctx context.Context, tx *sql.Tx, id int64, deleted bool) (*Product, error) {
+	query := `
+		SELECT product_id, name, description, category, image_url, price_amount, currency, COALESCE(price, ''), status, publish_at, version
+		FROM products
+		WHERE product_id = $1 AND (deleted_at IS NOT NULL) = $2
+		FOR UPDATE
+	`
+
+	var product Product
+	var priceAmount sql.NullInt64
+	var legacyPrice string
+	err := tx.QueryRowContext(ctx, query, id, deleted).Scan(
+		&product.ProductID,
+		&product.Name,
+		&product.Description,
+		&product.Category,
+		&product.ImageURL,
+		&priceAmount,
+		&product.Price.Currency,
+		&legacyPrice,
+		&product.Status,
+		&product.PublishAt,
+		&product.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	product.Price = productPrice(priceAmount, product.Price.Currency, legacyPrice)
+
+	return &product, nil
+}
+
+// auditFields returns the product fields recorded in the audit log. Ratings and scores are
+// left out, they change with every review rather than by anyone editing the product.
+func (product *Product) auditFields() map[string]any {
+	if product == nil {
+		return nil
+	}
+	return map[string]any{
+		"name":        product.Name,
+		"description": product.Description,
+		"category":    product.Category,
+		"image_url":   product.ImageURL,
+		"price":       product.Price,
+		"status":      product.Status,
+		"publish_at":  product.PublishAt,
+		"version":     product.Version,
+	}
+}
+
+// auditProduct records a change to a product in the audit log. before is nil for a new
+// product and after is nil for a deleted one.
+func (p ProductModel) auditProduct(ctx context.Context, tx *sql.Tx, action string, before, after *Product, actor AuditActor) error {
+	changes, err := auditChanges(before.auditFields(), after.auditFields())
+	if err != nil {
+		return err
+	}
+
+	entry := AuditEntry{
+		EntityType: AuditEntityProduct,
+		Action:     action,
+		Changes:    changes,
+	}
+	if after != nil {
+		entry.EntityID = after.ProductID
+	} else {
+		entry.EntityID = before.ProductID
+	}
+	return recordAudit(ctx, tx, &entry, actor)
 }
 
 // GetProduct retrieves a product by its ID from the database, returning an error if not found.
@@ -69,12 +195,15 @@ func (p ProductModel) GetProduct(id int64) (*Product, error) {
 	}
 
 	query := `
-		SELECT product_id, name, description, category, image_url, price, avg_rating, created_at, version
+		SELECT product_id, name, description, category, image_url, price_amount, currency, COALESCE(price, ''), avg_rating,
+			review_count, ` + p.scoreColumn() + `, status, publish_at, created_at, version
 		FROM products
-		WHERE product_id = $1
+		WHERE product_id = $1 AND deleted_at IS NULL
 	`
 
 	var product Product
+	var priceAmount sql.NullInt64
+	var legacyPrice string
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
@@ -84,8 +213,14 @@ func (p ProductModel) GetProduct(id int64) (*Product, error) {
 		&product.Description,
 		&product.Category,
 		&product.ImageURL,
-		&product.Price,
+		&priceAmount,
+		&product.Price.Currency,
+		&legacyPrice,
 		&product.AvgRating,
+		&product.ReviewCount,
+		&product.Score,
+		&product.Status,
+		&product.PublishAt,
 		&product.CreatedAt,
 		&product.Version,
 	)
@@ -96,74 +231,330 @@ func (p ProductModel) GetProduct(id int64) (*Product, error) {
 		}
 		return nil, err
 	}
+	product.Price = productPrice(priceAmount, product.Price.Currency, legacyPrice)
 
 	return &product, nil
 }
 
 // UpdateProduct updates an existing product in the database, incrementing its version for concurrency control.
-func (p ProductModel) UpdateProduct(product *Product) error {
+// The update only applies if the version is unchanged since the product was read, otherwise ErrEditConflict is returned.
+// The fields that changed are recorded in the audit log, in the same transaction, as changed by actor.
+func (p ProductModel) UpdateProduct(product *Product, actor AuditActor) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Has no effect once the transaction is committed.
+
+	before, err := lockProduct(ctx, tx, product.ProductID, false)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrRecordNotFound):
+			return ErrEditConflict // Someone else deleted the product first.
+		default:
+			return err
+		}
+	}
+	if before.Version != product.Version {
+		return ErrEditConflict // Someone else updated the product first.
+	}
+
 	query := `
 		UPDATE products
-		SET name = $1, description = $2, category = $3, image_url = $4, price = $5, avg_rating = $6, version = version + 1
-		WHERE product_id = $7
+		SET name = $1, description = $2, category = $3, image_url = $4, price_amount = $5, currency = $6, price = $7,
+			status = $8, publish_at = $9, version = version + 1
+		WHERE product_id = $10 AND version = $11
 		RETURNING version
 	`
 
 	// Removed `product.UpdatedAt` from the args slice
-	args := []any{product.Name, product.Description, product.Category, product.ImageURL, product.Price, product.AvgRating, product.ProductID}
+	// avg_rating and review_count are left alone, the reviews trigger maintains them
+	args := []any{product.Name, product.Description, product.Category, product.ImageURL, product.Price.Amount, product.Price.Currency, product.Price.String(), product.Status, product.PublishAt, product.ProductID, product.Version}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&product.Version)
+	if err != nil {
+		return err
+	}
 
-	return p.DB.QueryRowContext(ctx, query, args...).Scan(&product.Version)
+	err = p.auditProduct(ctx, tx, AuditActionUpdate, before, product, actor)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// DeleteProduct deletes a product by its ID from the database and checks that a row was deleted.
-func (p ProductModel) DeleteProduct(id int64) error {
+// DeleteProduct moves a product to the trash by its ID. Its reviews are kept, so that
+// restoring the product brings them back with it. The deletion is recorded in the audit log,
+// in the same transaction, as made by actor.
+func (p ProductModel) DeleteProduct(id int64, actor AuditActor) error {
 	if id < 1 {
 		return ErrRecordNotFound // Return an error if the ID is invalid.
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Has no effect once the transaction is committed.
+
+	// Returns ErrRecordNotFound if there is nothing to delete.
+	before, err := lockProduct(ctx, tx, id, false)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		DELETE FROM products
+		UPDATE products
+		SET deleted_at = NOW(), version = version + 1
 		WHERE product_id = $1
 	`
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	err = p.auditProduct(ctx, tx, AuditActionDelete, before, nil, actor)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RestoreProduct takes a product out of the trash, along with its reviews.
+// The restore is recorded in the audit log, in the same transaction, as made by actor.
+// Returns ErrRecordNotFound if the product isn't in the trash.
+func (p ProductModel) RestoreProduct(id int64, actor AuditActor) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	result, err := p.DB.ExecContext(ctx, query, id)
+	tx, err := p.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback() // Has no effect once the transaction is committed.
 
-	rowsAffected, err := result.RowsAffected()
+	product, err := lockProduct(ctx, tx, id, true)
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return ErrRecordNotFound // Return an error if no rows were deleted.
+	query := `
+		UPDATE products
+		SET deleted_at = NULL, version = version + 1
+		WHERE product_id = $1
+		RETURNING version
+	`
+	err = tx.QueryRowContext(ctx, query, id).Scan(&product.Version)
+	if err != nil {
+		return err
+	}
+
+	err = p.auditProduct(ctx, tx, AuditActionRestore, nil, product, actor)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PublishScheduled publishes the drafts whose publish_at time has arrived and returns how many
+// it published. Each one is recorded in the audit log, in the same transaction, as made by actor.
+// Drafts locked by another transaction, e.g. an admin editing them, are left for the next run.
+func (p ProductModel) PublishScheduled(actor AuditActor) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // Has no effect once the transaction is committed.
+
+	query := `
+		SELECT product_id
+		FROM products
+		WHERE status = 'draft' AND publish_at <= NOW() AND deleted_at IS NULL
+		ORDER BY publish_at
+		LIMIT 100
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		before, err := lockProduct(ctx, tx, id, false)
+		if err != nil {
+			return 0, err
+		}
+
+		after := *before
+		after.Status = ProductStatusPublished
+		err = tx.QueryRowContext(ctx, `UPDATE products SET status = $1, version = version + 1 WHERE product_id = $2 RETURNING version`,
+			after.Status, id).Scan(&after.Version)
+		if err != nil {
+			return 0, err
+		}
+
+		err = p.auditProduct(ctx, tx, AuditActionUpdate, before, &after, actor)
+		if err != nil {
+			return 0, err
+		}
 	}
 
-	return nil
+	return len(ids), tx.Commit()
 }
 
-// GetAllProducts retrieves all products from the database, with support for name/category filtering
+// RecalculateRatings rebuilds every product's review_count, rating_sum and avg_rating from
+// its approved reviews. The reviews trigger keeps them up to date, so this is only needed to
+// repair the totals, e.g. after reviews were changed with the trigger disabled. It returns
+// the number of products whose totals were wrong.
+func (p ProductModel) RecalculateRatings() (int64, error) {
+	query := `
+		WITH totals AS (
+			SELECT products.product_id,
+				COUNT(reviews.rating) AS review_count,
+				COALESCE(SUM(reviews.rating::numeric), 0) AS rating_sum
+			FROM products
+			LEFT JOIN reviews ON reviews.product_id = products.product_id
+				AND reviews.status = 'approved' AND reviews.rating IS NOT NULL AND reviews.deleted_at IS NULL
+			GROUP BY products.product_id
+		)
+		UPDATE products
+		SET review_count = totals.review_count,
+			rating_sum = totals.rating_sum,
+			avg_rating = COALESCE(ROUND(totals.rating_sum / NULLIF(totals.review_count, 0), 2), 0)
+		FROM totals
+		WHERE products.product_id = totals.product_id
+		AND (products.review_count <> totals.review_count OR products.rating_sum <> totals.rating_sum)
+	`
+
+	// Rebuilding every product can take a while on a large catalogue
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	result, err := p.DB.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ProductCriteria holds the optional conditions used to narrow down a product listing.
+// Nil pointers and empty values mean "no condition".
+type ProductCriteria struct {
+	Name          string     // Full-text match on the product name.
+	Categories    []string   // Match products in any of these categories.
+	Currency      string     // Currency that MinPrice and MaxPrice are expressed in.
+	MinPrice      *int64     // Lowest price in minor units.
+	MaxPrice      *int64     // Highest price in minor units.
+	MinRating     *float64   // Lowest average rating.
+	CreatedAfter  *time.Time // Only products created after this time.
+	CreatedBefore *time.Time // Only products created before this time.
+	Status        string     // Only products in this lifecycle state.
+}
+
+// ValidateProductCriteria checks that the listing conditions make sense together.
+func ValidateProductCriteria(v *validator.Validator, c ProductCriteria) {
+	v.Check(len(c.Categories) <= 10, "category", "must not contain more than 10 values") // Keep the category list short.
+	for _, category := range c.Categories {
+		v.Check(category != "", "category", "must not contain empty values") // No empty entries such as "a,,b".
+	}
+	v.Check(ValidCurrency(c.Currency), "currency", "must be a valid ISO 4217 currency code") // Price bounds need a real currency.
+	if c.MinPrice != nil && c.MaxPrice != nil {
+		v.Check(*c.MinPrice <= *c.MaxPrice, "min_price", "must not be greater than max_price") // Bounds must not cross.
+	}
+	if c.MinRating != nil {
+		v.Check(*c.MinRating >= 0 && *c.MinRating <= 5, "min_rating", "must be between 0 and 5") // Ratings run from 0 to 5.
+	}
+	if c.Status != "" {
+		v.Check(validator.PermittedValue(c.Status, ProductStatuses...), "status", "must be one of draft, published or archived") // Status must be known.
+	}
+	if c.CreatedAfter != nil && c.CreatedBefore != nil {
+		v.Check(c.CreatedAfter.Before(*c.CreatedBefore), "created_after", "must be earlier than created_before") // Range must not be empty.
+	}
+}
+
+// GetAllProducts retrieves all products from the database, with support for filtering by the given criteria
 // and pagination controlled by the provided Filters struct.
-func (p ProductModel) GetAllProducts(name string, category string, filters Filters) ([]*Product, Metadata, error) {
+func (p ProductModel) GetAllProducts(criteria ProductCriteria, filters Filters) ([]*Product, Metadata, error) {
+	// The price sort key is stored in the price_amount column, the legacy price column is free text.
+	// Nullable columns are coalesced so that cursors can always compare against them.
+	filters.SortColumns = map[string]string{
+		"price":      "COALESCE(price_amount, 0)",
+		"avg_rating": "COALESCE(avg_rating, 0)",
+		"score":      p.scoreColumn(),
+	}
+
+	// A cursor (already checked by ValidateFilters) replaces the OFFSET with a seek past its row.
+	cursor, _ := filters.decodeCursor()
+	seek, seekArgs := filters.seekPredicate(cursor, "product_id", 12)
+
+	// Price bounds only compare against products priced in the same currency.
 	query := fmt.Sprintf(`
-		SELECT COUNT(*) OVER(), product_id, name, description, category, image_url, price, avg_rating, created_at, version
+		SELECT COUNT(*) OVER(), product_id, name, description, category, image_url, price_amount, currency, COALESCE(price, ''), avg_rating,
+			review_count, %s, status, publish_at, created_at, version%s
 		FROM products
 		WHERE (to_tsvector('simple', name) @@ plainto_tsquery('simple', $1) OR $1 = '') 
-		AND (to_tsvector('simple', category) @@ plainto_tsquery('simple', $2) OR $2 = '') 
-		ORDER BY %s %s, product_id ASC 
-		LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+		AND deleted_at IS NULL
+		AND (COALESCE(cardinality($2::text[]), 0) = 0
+			OR to_tsvector('simple', category) @@ ANY (SELECT plainto_tsquery('simple', c) FROM unnest($2::text[]) AS c))
+		AND ($3::bigint IS NULL OR (currency = $5 AND price_amount >= $3))
+		AND ($4::bigint IS NULL OR (currency = $5 AND price_amount <= $4))
+		AND ($6::float8 IS NULL OR avg_rating >= $6)
+		AND ($7::timestamptz IS NULL OR created_at > $7)
+		AND ($8::timestamptz IS NULL OR created_at < $8)
+		AND (status = $11 OR $11 = '')
+		%s
+		ORDER BY %s
+		LIMIT $9 OFFSET $10`, p.scoreColumn(), filters.sortSelect(), seek, filters.orderBy("product_id", cursor != nil && cursor.Backward))
+
+	args := []any{
+		criteria.Name,
+		pq.Array(criteria.Categories),
+		criteria.MinPrice,
+		criteria.MaxPrice,
+		criteria.Currency,
+		criteria.MinRating,
+		criteria.CreatedAfter,
+		criteria.CreatedBefore,
+		filters.fetchLimit(),
+		filters.offset(),
+		criteria.Status,
+	}
+	args = append(args, seekArgs...)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := p.DB.QueryContext(ctx, query, name, category, filters.limit(), filters.offset())
+	rows, err := p.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
@@ -171,25 +562,38 @@ func (p ProductModel) GetAllProducts(name string, category string, filters Filte
 	defer rows.Close()
 	totalRecords := 0
 	products := []*Product{}
+	keys := []cursorKey{}
 
 	for rows.Next() {
 		var product Product
-		err := rows.Scan(
+		var priceAmount sql.NullInt64
+		var legacyPrice string
+		sortValues := filters.sortDestinations()
+		destinations := []any{
 			&totalRecords,
 			&product.ProductID,
 			&product.Name,
 			&product.Description,
 			&product.Category,
 			&product.ImageURL,
-			&product.Price,
+			&priceAmount,
+			&product.Price.Currency,
+			&legacyPrice,
 			&product.AvgRating,
+			&product.ReviewCount,
+			&product.Score,
+			&product.Status,
+			&product.PublishAt,
 			&product.CreatedAt,
 			&product.Version,
-		)
+		}
+		err := rows.Scan(append(destinations, sortValues...)...)
 		if err != nil {
 			return nil, Metadata{}, err
 		}
+		product.Price = productPrice(priceAmount, product.Price.Currency, legacyPrice)
 		products = append(products, &product)
+		keys = append(keys, newCursorKey(product.ProductID, sortValues))
 	}
 
 	err = rows.Err()
@@ -198,6 +602,22 @@ func (p ProductModel) GetAllProducts(name string, category string, filters Filte
 	}
 
 	// Calculate pagination metadata based on total records, current page, and page size.
+	// When seeking with a cursor the count only covers the rows after it, so page numbers are left out.
+	products, next, prev := finishPage(filters, cursor, products, keys)
 	metadata := calculateMetaData(totalRecords, filters.Page, filters.PageSize)
+	if cursor != nil {
+		metadata = Metadata{PageSize: filters.PageSize}
+	}
+	metadata.NextCursor = next
+	metadata.PrevCursor = prev
 	return products, metadata, nil
 }
+
+// productPrice builds a product's price from the minor-unit columns, falling back to the
+// legacy free-text price column for rows that the migration could not convert.
+func productPrice(amount sql.NullInt64, currency string, legacyPrice string) Money {
+	if amount.Valid {
+		return Money{Amount: amount.Int64, Currency: currency}
+	}
+	return legacyMoney(legacyPrice, currency)
+}
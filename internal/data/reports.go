@@ -0,0 +1,206 @@
+// Filename: internal/data/reports.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// The reasons a user can give for reporting a review.
+const (
+	ReportReasonSpam      = "spam"
+	ReportReasonOffensive = "offensive"
+	ReportReasonOffTopic  = "off_topic"
+)
+
+// ReportReasons lists every reason a review can be reported for.
+var ReportReasons = []string{ReportReasonSpam, ReportReasonOffensive, ReportReasonOffTopic}
+
+// ReviewReport is one user's complaint about a review.
+type ReviewReport struct {
+	ReviewID  int64     `json:"review_id"`         // Review being reported
+	UserID    int64     `json:"user_id"`           // User making the report
+	Reason    string    `json:"reason"`            // One of ReportReasons
+	Details   string    `json:"details,omitempty"` // Optional explanation from the user
+	CreatedAt time.Time `json:"created_at"`        // When the report was made
+}
+
+// ReportedReview is a review together with a summary of the reports made against it.
+type ReportedReview struct {
+	Review         *Review        `json:"review"`
+	ReportCount    int            `json:"report_count"`     // Number of distinct users who reported the review
+	Reasons        map[string]int `json:"reasons"`          // Number of reports for each reason
+	LastReportedAt time.Time      `json:"last_reported_at"` // When the most recent report was made
+}
+
+// ReviewReportModel wraps the database connection pool for managing review reports.
+type ReviewReportModel struct {
+	DB *sql.DB
+}
+
+// ValidateReviewReport checks the reason and the size of the details.
+func ValidateReviewReport(v *validator.Validator, report *ReviewReport) {
+	v.Check(validator.PermittedValue(report.Reason, ReportReasons...), "reason", "must be one of spam, offensive or off_topic")
+	v.Check(len(report.Details) <= 500, "details", "must not be more than 500 bytes long")
+}
+
+// InsertReport records a report against a review. Once hideAfter distinct users have reported
+// an approved review since a moderator last decided on it, it is hidden until a moderator looks
+// at it again; hidden reports whether this report was the one that hid it. ErrDuplicateReport is returned if the user already reported
+// the review, and ErrRecordNotFound if the review doesn't exist.
+func (m ReviewReportModel) InsertReport(report *ReviewReport, hideAfter int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // Has no effect once the transaction is committed
+
+	// Reports on the same review are counted one after the other
+	err = lockReview(ctx, tx, report.ReviewID)
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+		INSERT INTO review_reports (review_id, user_id, reason, details)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (review_id, user_id) DO NOTHING
+		RETURNING created_at
+	`
+	err = tx.QueryRowContext(ctx, query, report.ReviewID, report.UserID, report.Reason, report.Details).Scan(&report.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return false, ErrDuplicateReport
+		default:
+			return false, err
+		}
+	}
+
+	// Reports made before a moderator's last decision have already been dealt with
+	query = `
+		SELECT COUNT(*)
+		FROM review_reports
+		JOIN reviews ON reviews.review_id = review_reports.review_id
+		WHERE review_reports.review_id = $1
+		AND (reviews.moderated_at IS NULL OR review_reports.created_at > reviews.moderated_at)
+	`
+	var reports int
+	err = tx.QueryRowContext(ctx, query, report.ReviewID).Scan(&reports)
+	if err != nil {
+		return false, err
+	}
+
+	hidden := false
+	if hideAfter > 0 && reports >= hideAfter {
+		query = `
+			UPDATE reviews
+			SET status = 'hidden', moderation_reason = $2, moderated_by = NULL, moderated_at = NOW(), version = version + 1
+			WHERE review_id = $1 AND status = 'approved'
+		`
+		reason := fmt.Sprintf("hidden automatically after %d reports", reports)
+		result, err := tx.ExecContext(ctx, query, report.ReviewID, reason)
+		if err != nil {
+			return false, err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		hidden = rowsAffected > 0
+	}
+
+	return hidden, tx.Commit()
+}
+
+// GetReportedReviews lists the reviews that have been reported, most reported first unless
+// filters asks for another sort. status limits the list to reviews in that moderation
+// status; an empty status lists them all.
+func (m ReviewReportModel) GetReportedReviews(status string, filters Filters) ([]*ReportedReview, Metadata, error) {
+	filters.SortColumns = map[string]string{
+		"report_count":     "counts.report_count",
+		"last_reported_at": "counts.last_reported_at",
+	}
+
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) OVER(), reviews.review_id, product_id, COALESCE(reviews.user_id, 0), author, rating, comment,
+			helpful_count, unhelpful_count, legacy_helpful_count, status, COALESCE(moderated_by, 0), moderated_at, moderation_reason,
+			reviews.created_at, edited_at, version, counts.report_count, counts.spam, counts.offensive, counts.off_topic, counts.last_reported_at
+		FROM reviews
+		INNER JOIN (
+			SELECT review_id, COUNT(*) AS report_count,
+				COUNT(*) FILTER (WHERE reason = 'spam') AS spam,
+				COUNT(*) FILTER (WHERE reason = 'offensive') AS offensive,
+				COUNT(*) FILTER (WHERE reason = 'off_topic') AS off_topic,
+				MAX(created_at) AS last_reported_at
+			FROM review_reports
+			GROUP BY review_id
+		) AS counts ON counts.review_id = reviews.review_id
+		WHERE (status = $1 OR $1 = '') AND reviews.deleted_at IS NULL
+		ORDER BY %s
+		LIMIT $2 OFFSET $3`, filters.orderBy("reviews.review_id", false))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, status, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	reported := []*ReportedReview{}
+	for rows.Next() {
+		var review Review
+		var spam, offensive, offTopic int
+		entry := ReportedReview{Review: &review}
+		err := rows.Scan(
+			&totalRecords,
+			&review.ReviewID,
+			&review.ProductID,
+			&review.UserID,
+			&review.Author,
+			&review.Rating,
+			&review.Comment,
+			&review.HelpfulCount,
+			&review.UnhelpfulCount,
+			&review.LegacyHelpful,
+			&review.Status,
+			&review.ModeratedBy,
+			&review.ModeratedAt,
+			&review.ModerationReason,
+			&review.CreatedAt,
+			&review.EditedAt,
+			&review.Version,
+			&entry.ReportCount,
+			&spam,
+			&offensive,
+			&offTopic,
+			&entry.LastReportedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		entry.Reasons = map[string]int{
+			ReportReasonSpam:      spam,
+			ReportReasonOffensive: offensive,
+			ReportReasonOffTopic:  offTopic,
+		}
+		reported = append(reported, &entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetaData(totalRecords, filters.Page, filters.PageSize)
+	return reported, metadata, nil
+}
@@ -13,16 +13,36 @@ import (
 
 // Review struct represents a review for a product, with various attributes related to the review's content and metadata.
 type Review struct {
-	ReviewID     int64     `json:"review_id"`     // Unique identifier for the review (primary key)
-	ProductID    int64     `json:"product_id"`    // Identifier of the product being reviewed (foreign key)
-	Author       string    `json:"author"`        // Name of the review's author
-	Rating       int64     `json:"rating"`        // Rating given by the author, constrained to values between 1 and 5
-	Comment      string    `json:"commentt"`      // Content of the comment, required field
-	HelpfulCount int32     `json:"helpful_count"` // Number of "helpful" votes, defaults to 0 if not specified
-	CreatedAt    time.Time `json:"-"`             // Timestamp for when the review was created, auto-set to current time
-	Version      int       `json:"version"`       // Version number to track changes to the review
+	ReviewID         int64      `json:"review_id"`                   // Unique identifier for the review (primary key)
+	ProductID        int64      `json:"product_id"`                  // Identifier of the product being reviewed (foreign key)
+	UserID           int64      `json:"user_id"`                     // Identifier of the user who wrote the review (foreign key, 0 for legacy reviews)
+	Author           string     `json:"author"`                      // Display name of the review's author, taken from the user
+	Rating           int64      `json:"rating"`                      // Rating given by the author, constrained to values between 1 and 5
+	Comment          string     `json:"commentt"`                    // Content of the comment, required field
+	HelpfulCount     int32      `json:"helpful_count"`               // Number of "helpful" votes, kept in step with review_votes
+	UnhelpfulCount   int32      `json:"unhelpful_count"`             // Number of "unhelpful" votes, kept in step with review_votes
+	LegacyHelpful    int32      `json:"legacy_helpful_count"`        // Helpful marks made before votes were tracked per user, read-only and not part of HelpfulCount
+	Status           string     `json:"status"`                      // Moderation state, only approved reviews are public
+	ModeratedBy      int64      `json:"moderated_by,omitempty"`      // Moderator who last changed the status, 0 if nobody has
+	ModeratedAt      *time.Time `json:"moderated_at,omitempty"`      // When the status was last changed by a moderator
+	ModerationReason string     `json:"moderation_reason,omitempty"` // Why the moderator approved, rejected or hid the review
+	CreatedAt        time.Time  `json:"-"`                           // Timestamp for when the review was created, auto-set to current time
+	EditedAt         *time.Time `json:"edited_at,omitempty"`         // When the author or a moderator last changed the content, nil if never edited
+	Version          int        `json:"version"`                     // Version number to track changes to the review
 }
 
+// The moderation states of a review. New reviews wait as pending until a moderator
+// approves or rejects them; approved reviews can later be hidden.
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+	ReviewStatusHidden   = "hidden"
+)
+
+// ReviewStatuses lists every moderation state, in the order they are usually reached.
+var ReviewStatuses = []string{ReviewStatusPending, ReviewStatusApproved, ReviewStatusRejected, ReviewStatusHidden}
+
 // ReviewModel wraps the database connection pool for managing review data.
 type ReviewModel struct {
 	DB *sql.DB // Database connection pool
@@ -40,31 +60,137 @@ func ValidateReview(v *validator.Validator, review *Review) {
 // InsertReview adds a new review to the database and retrieves its ID, creation timestamp, and version.
 func (c ReviewModel) InsertReview(review *Review) error {
 	query := `
-		INSERT INTO reviews (product_id, author, rating, comment, helpful_count)
-		VALUES ($1, $2, $3, $4, COALESCE($5, 0))
+		INSERT INTO reviews (product_id, user_id, author, rating, comment, status, moderation_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING review_id, created_at, version
 	`
-	args := []any{review.ProductID, review.Author, review.Rating, review.Comment, review.HelpfulCount}
+	// A new review has no votes, so its helpful and unhelpful counts start at zero
+	args := []any{review.ProductID, review.UserID, review.Author, review.Rating, review.Comment, review.Status, review.ModerationReason}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel() // Ensure the timeout context is canceled to free up resources
 
 	// Execute query and store the new review's ID, creation timestamp, and version
-	return c.DB.QueryRowContext(ctx, query, args...).Scan(
+	err := c.DB.QueryRowContext(ctx, query, args...).Scan(
 		&review.ReviewID,
 		&review.CreatedAt,
 		&review.Version)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "reviews_user_id_product_id_key"`:
+			return ErrDuplicateReview // The user has already reviewed this product
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpsertReview creates the user's review of a product, or replaces it if they already wrote one.
+// The user's review is locked while it is replaced, and the version being replaced is kept as a
+// revision in the same transaction. When expectedVersion is not zero an existing review is only
+// replaced if it is still at that version, otherwise ErrEditConflict is returned, as it is when
+// another request creates the user's review at the same time. created reports whether a new
+// review was made.
+func (c ReviewModel) UpsertReview(review *Review, expectedVersion int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // Has no effect once the transaction is committed
+
+	var currentVersion int
+	err = tx.QueryRowContext(ctx, `SELECT review_id, version FROM reviews WHERE user_id = $1 AND product_id = $2 AND deleted_at IS NULL FOR UPDATE`,
+		review.UserID, review.ProductID).Scan(&review.ReviewID, &currentVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		if expectedVersion != 0 {
+			return false, ErrEditConflict // The client expected to replace a review that isn't there
+		}
+		query := `
+			INSERT INTO reviews (product_id, user_id, author, rating, comment, status, moderation_reason)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (user_id, product_id) WHERE deleted_at IS NULL DO NOTHING
+			RETURNING review_id, created_at, version
+		`
+		args := []any{review.ProductID, review.UserID, review.Author, review.Rating, review.Comment, review.Status, review.ModerationReason}
+		err = tx.QueryRowContext(ctx, query, args...).Scan(&review.ReviewID, &review.CreatedAt, &review.Version)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return false, ErrEditConflict // Another request created the review first
+			default:
+				return false, err
+			}
+		}
+		return true, tx.Commit()
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if expectedVersion != 0 && expectedVersion != currentVersion {
+		return false, ErrEditConflict
+	}
+
+	err = saveRevision(ctx, tx, review.ReviewID, currentVersion, review.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	// A replaced review starts moderation over
+	query := `
+		UPDATE reviews
+		SET author = $1, rating = $2, comment = $3, status = $4, moderation_reason = $5,
+			moderated_by = NULL, moderated_at = NULL, edited_at = NOW(), version = version + 1
+		WHERE review_id = $6
+		RETURNING helpful_count, unhelpful_count, legacy_helpful_count, created_at, edited_at, version
+	`
+	args := []any{review.Author, review.Rating, review.Comment, review.Status, review.ModerationReason, review.ReviewID}
+	err = tx.QueryRowContext(ctx, query, args...).Scan(
+		&review.HelpfulCount,
+		&review.UnhelpfulCount,
+		&review.LegacyHelpful,
+		&review.CreatedAt,
+		&review.EditedAt,
+		&review.Version,
+	)
+	if err != nil {
+		return false, err
+	}
+	review.ModeratedBy = 0
+	review.ModeratedAt = nil
+
+	return false, tx.Commit()
 }
 
-// GetReview retrieves a single review by its ID. Returns ErrRecordNotFound if no review is found.
+// GetReview retrieves a single review by its ID. Returns ErrRecordNotFound if no review is found,
+// the review is in the trash, or its product isn't published or is in the trash.
 func (c ReviewModel) GetReview(id int64) (*Review, error) {
+	return c.getReview(id, false)
+}
+
+// GetDeletedReview retrieves a review from the trash by its ID.
+// Returns ErrRecordNotFound if no such review is in the trash.
+func (c ReviewModel) GetDeletedReview(id int64) (*Review, error) {
+	return c.getReview(id, true)
+}
+
+// getReview retrieves a review by its ID, either from the trash or from outside it.
+// Reviews outside the trash are only found while their product is visible to the public;
+// trashed reviews are found either way so that they can still be restored.
+func (c ReviewModel) getReview(id int64, deleted bool) (*Review, error) {
 	if id < 1 {
 		return nil, ErrRecordNotFound // Validates ID input to avoid invalid queries
 	}
 	query := `
-		SELECT review_id, product_id, author, rating, comment, helpful_count, created_at, version
+		SELECT review_id, product_id, COALESCE(user_id, 0), author, rating, comment, helpful_count, unhelpful_count, legacy_helpful_count, status, COALESCE(moderated_by, 0), moderated_at, moderation_reason, created_at, edited_at, version
 		FROM reviews
-		WHERE review_id = $1
+		WHERE review_id = $1 AND (deleted_at IS NOT NULL) = $2
+		AND ($2 OR EXISTS (SELECT 1 FROM products WHERE products.product_id = reviews.product_id AND products.deleted_at IS NULL AND products.status = 'published'))
 	`
 	var review Review
 
@@ -72,14 +198,65 @@ func (c ReviewModel) GetReview(id int64) (*Review, error) {
 	defer cancel()
 
 	// Execute query to fetch review details
-	err := c.DB.QueryRowContext(ctx, query, id).Scan(
+	err := c.DB.QueryRowContext(ctx, query, id, deleted).Scan(
 		&review.ReviewID,
 		&review.ProductID,
+		&review.UserID,
 		&review.Author,
 		&review.Rating,
 		&review.Comment,
 		&review.HelpfulCount,
+		&review.UnhelpfulCount,
+		&review.LegacyHelpful,
+		&review.Status,
+		&review.ModeratedBy,
+		&review.ModeratedAt,
+		&review.ModerationReason,
 		&review.CreatedAt,
+		&review.EditedAt,
+		&review.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetUserProductReview retrieves the review a user wrote for a product.
+// Returns ErrRecordNotFound if they haven't reviewed it.
+func (c ReviewModel) GetUserProductReview(userID int64, productID int64) (*Review, error) {
+	if userID < 1 || productID < 1 {
+		return nil, ErrRecordNotFound
+	}
+	query := `
+		SELECT review_id, product_id, COALESCE(user_id, 0), author, rating, comment, helpful_count, unhelpful_count, legacy_helpful_count, status, COALESCE(moderated_by, 0), moderated_at, moderation_reason, created_at, edited_at, version
+		FROM reviews
+		WHERE user_id = $1 AND product_id = $2 AND deleted_at IS NULL
+	`
+	var review Review
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := c.DB.QueryRowContext(ctx, query, userID, productID).Scan(
+		&review.ReviewID,
+		&review.ProductID,
+		&review.UserID,
+		&review.Author,
+		&review.Rating,
+		&review.Comment,
+		&review.HelpfulCount,
+		&review.UnhelpfulCount,
+		&review.LegacyHelpful,
+		&review.Status,
+		&review.ModeratedBy,
+		&review.ModeratedAt,
+		&review.ModerationReason,
+		&review.CreatedAt,
+		&review.EditedAt,
 		&review.Version,
 	)
 	if err != nil {
@@ -92,29 +269,91 @@ func (c ReviewModel) GetReview(id int64) (*Review, error) {
 }
 
 // UpdateReview modifies an existing review's details and increments its version number.
-func (c ReviewModel) UpdateReview(review *Review) error {
+// The version being replaced is kept as a revision, edited by editorID, in the same transaction.
+// The moderator fields are written too, so clearing them marks the status as no longer a moderator's.
+// The update only applies if the version is unchanged since the review was read, otherwise ErrEditConflict is returned.
+func (c ReviewModel) UpdateReview(review *Review, editorID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Has no effect once the transaction is committed
+
+	// Fails with ErrEditConflict if someone else updated (or deleted) the review first
+	err = saveRevision(ctx, tx, review.ReviewID, review.Version, editorID)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE reviews
-		SET author = $1, rating = $2, comment = $3, version = version + 1
-		WHERE review_id = $4
-		RETURNING version
+		SET author = $1, rating = $2, comment = $3, status = $4, moderation_reason = $5,
+			moderated_by = NULLIF($6, 0), moderated_at = $7, edited_at = NOW(), version = version + 1
+		WHERE review_id = $8 AND version = $9
+		RETURNING edited_at, version
 	`
-	args := []any{review.Author, review.Rating, review.Comment, review.ReviewID}
+	args := []any{review.Author, review.Rating, review.Comment, review.Status, review.ModerationReason,
+		review.ModeratedBy, review.ModeratedAt, review.ReviewID, review.Version}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&review.EditedAt, &review.Version) // Update version for tracking changes
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ModerateReview records a moderator's decision on a review: its new status, the reason,
+// who made the decision and when. Like UpdateReview it only applies to the version that
+// was read, otherwise ErrEditConflict is returned.
+func (c ReviewModel) ModerateReview(review *Review) error {
+	query := `
+		UPDATE reviews
+		SET status = $1, moderation_reason = $2, moderated_by = $3, moderated_at = NOW(), version = version + 1
+		WHERE review_id = $4 AND version = $5
+		RETURNING moderated_at, version
+	`
+	args := []any{review.Status, review.ModerationReason, review.ModeratedBy, review.ReviewID, review.Version}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	return c.DB.QueryRowContext(ctx, query, args...).Scan(&review.Version) // Update version for tracking changes
+	err := c.DB.QueryRowContext(ctx, query, args...).Scan(&review.ModeratedAt, &review.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
 }
 
-// DeleteReview removes a review from the database by ID.
+// ValidateModeration checks a moderator's decision: the status must be known, and
+// rejecting or hiding a review needs a reason that can be shown to its author.
+func ValidateModeration(v *validator.Validator, review *Review) {
+	v.Check(validator.PermittedValue(review.Status, ReviewStatuses...), "status", "must be one of pending, approved, rejected or hidden")
+	if review.Status == ReviewStatusRejected || review.Status == ReviewStatusHidden {
+		v.Check(review.ModerationReason != "", "reason", "must be provided when rejecting or hiding a review")
+	}
+	v.Check(len(review.ModerationReason) <= 500, "reason", "must not be more than 500 bytes long")
+}
+
+// DeleteReview moves a review to the trash by ID. It stays there, hidden from everyone,
+// until it is restored or purged.
 func (c ReviewModel) DeleteReview(id int64) error {
 	if id < 1 {
 		return ErrRecordNotFound // Validate ID to prevent unnecessary database operations
 	}
 	query := `
-		DELETE FROM reviews
-		WHERE review_id = $1
+		UPDATE reviews
+		SET deleted_at = NOW(), version = version + 1
+		WHERE review_id = $1 AND deleted_at IS NULL
 	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -136,19 +375,65 @@ func (c ReviewModel) DeleteReview(id int64) error {
 	return nil
 }
 
+// RestoreReview takes a review out of the trash. Returns ErrRecordNotFound if the review
+// isn't in the trash, and ErrDuplicateReview if its author has written another review of
+// the product since it was deleted.
+func (c ReviewModel) RestoreReview(review *Review) error {
+	query := `
+		UPDATE reviews
+		SET deleted_at = NULL, version = version + 1
+		WHERE review_id = $1 AND deleted_at IS NOT NULL
+		RETURNING version
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := c.DB.QueryRowContext(ctx, query, review.ReviewID).Scan(&review.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		case err.Error() == `pq: duplicate key value violates unique constraint "reviews_user_id_product_id_key"`:
+			return ErrDuplicateReview
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
 // GetAllReviews retrieves a list of reviews matching a given author name with sorting and pagination.
-func (c ReviewModel) GetAllReviews(author string, filters Filters) ([]*Review, Metadata, error) {
+// Only reviews in the given moderation status are returned, or reviews in any status when it is empty.
+func (c ReviewModel) GetAllReviews(author string, status string, filters Filters) ([]*Review, Metadata, error) {
+	// Nullable columns are coalesced so that cursors can always compare against them
+	filters.SortColumns = map[string]string{
+		"author":        "COALESCE(author, '')",
+		"rating":        "COALESCE(rating, 0)",
+		"helpful_count": "COALESCE(helpful_count, 0)",
+	}
+
+	// A cursor (already checked by ValidateFilters) replaces the OFFSET with a seek past its row
+	cursor, _ := filters.decodeCursor()
+	seek, seekArgs := filters.seekPredicate(cursor, "review_id", 5)
+
 	query := fmt.Sprintf(`
-	SELECT COUNT(*) OVER(), review_id, product_id, author, rating, comment, helpful_count, created_at, version
+	SELECT COUNT(*) OVER(), review_id, product_id, COALESCE(user_id, 0), author, rating, comment, helpful_count, unhelpful_count, legacy_helpful_count, status, COALESCE(moderated_by, 0), moderated_at, moderation_reason, created_at, edited_at, version%s
 	FROM reviews
 	WHERE (to_tsvector('simple', author) @@ plainto_tsquery('simple', $1) OR $1 = '') 
-	ORDER BY %s %s, review_id ASC 
-	LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+	AND (status = $4 OR $4 = '')
+	AND deleted_at IS NULL
+	AND NOT EXISTS (SELECT 1 FROM products WHERE products.product_id = reviews.product_id AND (products.deleted_at IS NOT NULL OR products.status <> 'published'))
+	%s
+	ORDER BY %s
+	LIMIT $2 OFFSET $3`, filters.sortSelect(), seek, filters.orderBy("review_id", cursor != nil && cursor.Backward))
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := c.DB.QueryContext(ctx, query, author, filters.limit(), filters.offset())
+	args := append([]any{author, filters.fetchLimit(), filters.offset(), status}, seekArgs...)
+	rows, err := c.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
@@ -156,14 +441,18 @@ func (c ReviewModel) GetAllReviews(author string, filters Filters) ([]*Review, M
 
 	var totalRecords int
 	reviews := []*Review{}
+	keys := []cursorKey{}
 
 	// Process each row and populate reviews slice
 	for rows.Next() {
 		var review Review
-		if err := rows.Scan(&totalRecords, &review.ReviewID, &review.ProductID, &review.Author, &review.Rating, &review.Comment, &review.HelpfulCount, &review.CreatedAt, &review.Version); err != nil {
+		sortValues := filters.sortDestinations()
+		destinations := []any{&totalRecords, &review.ReviewID, &review.ProductID, &review.UserID, &review.Author, &review.Rating, &review.Comment, &review.HelpfulCount, &review.UnhelpfulCount, &review.LegacyHelpful, &review.Status, &review.ModeratedBy, &review.ModeratedAt, &review.ModerationReason, &review.CreatedAt, &review.EditedAt, &review.Version}
+		if err := rows.Scan(append(destinations, sortValues...)...); err != nil {
 			return nil, Metadata{}, err
 		}
 		reviews = append(reviews, &review)
+		keys = append(keys, newCursorKey(review.ReviewID, sortValues))
 	}
 
 	// Check for any row iteration errors
@@ -172,89 +461,109 @@ func (c ReviewModel) GetAllReviews(author string, filters Filters) ([]*Review, M
 	}
 
 	// Calculate pagination metadata
+	// When seeking with a cursor the count only covers the rows after it, so page numbers are left out
+	reviews, next, prev := finishPage(filters, cursor, reviews, keys)
 	metadata := calculateMetaData(totalRecords, filters.Page, filters.PageSize)
+	if cursor != nil {
+		metadata = Metadata{PageSize: filters.PageSize}
+	}
+	metadata.NextCursor = next
+	metadata.PrevCursor = prev
 
 	return reviews, metadata, nil
 }
 
-// GetAllProductReviews fetches all reviews associated with a specified product ID.
-func (c ReviewModel) GetAllProductReviews(productID int64) ([]Review, error) {
-	if productID < 1 {
-		return nil, ErrRecordNotFound // Validate product ID before querying
+// ProductReviewCriteria holds the optional conditions used to narrow down a product's reviews.
+// Nil pointers and false mean "no condition".
+type ProductReviewCriteria struct {
+	Rating       *int64 // Only reviews with exactly this rating.
+	MinRating    *int64 // Only reviews rated at least this.
+	WithComments bool   // Only reviews that have been commented on.
+}
+
+// ValidateProductReviewCriteria checks that the rating conditions are within the rating scale.
+func ValidateProductReviewCriteria(v *validator.Validator, c ProductReviewCriteria) {
+	if c.Rating != nil {
+		v.Check(*c.Rating >= 1 && *c.Rating <= 5, "rating", "must be between 1 and 5")
 	}
+	if c.MinRating != nil {
+		v.Check(*c.MinRating >= 1 && *c.MinRating <= 5, "min_rating", "must be between 1 and 5")
+	}
+}
 
-	query := `
-		SELECT review_id, author, rating, comment, helpful_count, created_at, version
-		FROM reviews
-		WHERE product_id = $1
-	`
-	var reviews []Review
+// GetAllProductReviews fetches a page of the approved reviews of a product, narrowed down by
+// the criteria and sorted and paginated like GetAllReviews.
+func (c ReviewModel) GetAllProductReviews(productID int64, criteria ProductReviewCriteria, filters Filters) ([]*Review, Metadata, error) {
+	// Nullable columns are coalesced so that cursors can always compare against them
+	filters.SortColumns = map[string]string{
+		"author":        "COALESCE(author, '')",
+		"rating":        "COALESCE(rating, 0)",
+		"helpful_count": "COALESCE(helpful_count, 0)",
+	}
+
+	// A cursor (already checked by ValidateFilters) replaces the OFFSET with a seek past its row
+	cursor, _ := filters.decodeCursor()
+	seek, seekArgs := filters.seekPredicate(cursor, "review_id", 7)
+
+	query := fmt.Sprintf(`
+	SELECT COUNT(*) OVER(), review_id, product_id, COALESCE(user_id, 0), author, rating, comment, helpful_count, unhelpful_count, legacy_helpful_count, status, COALESCE(moderated_by, 0), moderated_at, moderation_reason, created_at, edited_at, version%s
+	FROM reviews
+	WHERE product_id = $1 AND status = 'approved' AND deleted_at IS NULL
+	AND ($2::bigint IS NULL OR rating = $2)
+	AND ($3::bigint IS NULL OR rating >= $3)
+	AND (NOT $4 OR EXISTS (SELECT 1 FROM comments WHERE comments.review_id = reviews.review_id))
+	%s
+	ORDER BY %s
+	LIMIT $5 OFFSET $6`, filters.sortSelect(), seek, filters.orderBy("review_id", cursor != nil && cursor.Backward))
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := c.DB.QueryContext(ctx, query, productID)
+	args := []any{productID, criteria.Rating, criteria.MinRating, criteria.WithComments, filters.fetchLimit(), filters.offset()}
+	args = append(args, seekArgs...)
+	rows, err := c.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, err
 	}
 	defer rows.Close()
 
+	var totalRecords int
+	reviews := []*Review{}
+	keys := []cursorKey{}
+
 	// Scan each row and populate reviews slice
 	for rows.Next() {
 		var review Review
-		err := rows.Scan(
-			&review.ReviewID,
-			&review.Author,
-			&review.Rating,
-			&review.Comment,
-			&review.HelpfulCount,
-			&review.CreatedAt,
-			&review.Version,
-		)
-		if err != nil {
-			return nil, err
+		sortValues := filters.sortDestinations()
+		destinations := []any{&totalRecords, &review.ReviewID, &review.ProductID, &review.UserID, &review.Author, &review.Rating, &review.Comment, &review.HelpfulCount, &review.UnhelpfulCount, &review.LegacyHelpful, &review.Status, &review.ModeratedBy, &review.ModeratedAt, &review.ModerationReason, &review.CreatedAt, &review.EditedAt, &review.Version}
+		if err := rows.Scan(append(destinations, sortValues...)...); err != nil {
+			return nil, Metadata{}, err
 		}
-		reviews = append(reviews, review)
+		reviews = append(reviews, &review)
+		keys = append(keys, newCursorKey(review.ReviewID, sortValues))
 	}
 
 	// Check for errors after row iteration
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, err
 	}
 
-	return reviews, nil
-}
-
-// UpdateHelpfulCount increments the helpful_count for a review by 1.
-func (c *ReviewModel) UpdateHelpfulCount(id int64) (*Review, error) {
-	query := `
-        UPDATE reviews
-        SET helpful_count = helpful_count + 1
-        WHERE review_id = $1
-        RETURNING review_id, author, rating, comment, helpful_count, version
-    `
-	var review Review
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	// Update the helpful count and retrieve updated review fields
-	err := c.DB.QueryRowContext(ctx, query, id).Scan(
-		&review.ReviewID,
-		&review.Author,
-		&review.Rating,
-		&review.Comment,
-		&review.HelpfulCount,
-		&review.Version,
-	)
-	if err != nil {
-		return nil, err
+	// When seeking with a cursor the count only covers the rows after it, so page numbers are left out
+	reviews, next, prev := finishPage(filters, cursor, reviews, keys)
+	metadata := calculateMetaData(totalRecords, filters.Page, filters.PageSize)
+	if cursor != nil {
+		metadata = Metadata{PageSize: filters.PageSize}
 	}
+	metadata.NextCursor = next
+	metadata.PrevCursor = prev
 
-	return &review, nil
+	return reviews, metadata, nil
 }
 
+// ProductExists reports whether a product is visible to the public: it has been published
+// and isn't in the trash. Reviews can only be read and written for such products.
 func (m *ProductModel) ProductExists(productID int64) (bool, error) {
-	query := `SELECT EXISTS (SELECT 1 FROM products WHERE product_id = $1)`
+	query := `SELECT EXISTS (SELECT 1 FROM products WHERE product_id = $1 AND deleted_at IS NULL AND status = 'published')`
 	var exists bool
 	err := m.DB.QueryRow(query, productID).Scan(&exists)
 	if err != nil {
@@ -264,7 +573,7 @@ func (m *ProductModel) ProductExists(productID int64) (bool, error) {
 }
 func (m *ReviewModel) Exists(id int64) (bool, error) {
 	var exists bool
-	query := `SELECT EXISTS(SELECT 1 FROM reviews WHERE review_id = $1)`
+	query := `SELECT EXISTS(SELECT 1 FROM reviews WHERE review_id = $1 AND deleted_at IS NULL)`
 	err := m.DB.QueryRow(query, id).Scan(&exists)
 	if err != nil {
 		return false, err
@@ -279,9 +588,10 @@ func (c ReviewModel) GetProductReview(rid int64, pid int64) (*Review, error) {
 	}
 
 	//query
-	query := `SELECT review_id, product_id, author, rating, comment, helpful_count, created_at, version
+	query := `SELECT review_id, product_id, COALESCE(user_id, 0), author, rating, comment, helpful_count, unhelpful_count, legacy_helpful_count, status, COALESCE(moderated_by, 0), moderated_at, moderation_reason, created_at, edited_at, version
 	FROM reviews
-	WHERE review_id = $1 AND product_id = $2
+	WHERE review_id = $1 AND product_id = $2 AND deleted_at IS NULL
+	AND EXISTS (SELECT 1 FROM products WHERE products.product_id = reviews.product_id AND products.deleted_at IS NULL AND products.status = 'published')
 	`
 	var review Review
 
@@ -291,11 +601,19 @@ func (c ReviewModel) GetProductReview(rid int64, pid int64) (*Review, error) {
 	err := c.DB.QueryRowContext(ctx, query, rid, pid).Scan(
 		&review.ReviewID,
 		&review.ProductID,
+		&review.UserID,
 		&review.Author,
 		&review.Rating,
 		&review.Comment,
 		&review.HelpfulCount,
+		&review.UnhelpfulCount,
+		&review.LegacyHelpful,
+		&review.Status,
+		&review.ModeratedBy,
+		&review.ModeratedAt,
+		&review.ModerationReason,
 		&review.CreatedAt,
+		&review.EditedAt,
 		&review.Version,
 	)
 
@@ -0,0 +1,131 @@
+// Filename: internal/data/revisions.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ReviewRevision is an earlier version of a review, kept when the review was edited.
+type ReviewRevision struct {
+	ReviewID   int64     `json:"review_id"`
+	Version    int       `json:"version"`               // Version of the review this content belonged to
+	Author     string    `json:"author"`                // Author's display name at that version
+	Rating     int64     `json:"rating"`                // Rating at that version
+	Comment    string    `json:"comment"`               // Review text at that version
+	Status     string    `json:"status"`                // Moderation state at that version
+	CreatedAt  time.Time `json:"created_at"`            // When this content was written
+	ReplacedAt time.Time `json:"replaced_at"`           // When the next version replaced it
+	ReplacedBy int64     `json:"replaced_by,omitempty"` // User who made the edit, 0 if they were deleted
+}
+
+// saveRevision copies the review, as it is at version, into review_revisions before it is
+// changed by editorID. The review row is locked for the rest of the transaction; if it is no
+// longer at that version ErrEditConflict is returned.
+func saveRevision(ctx context.Context, tx *sql.Tx, reviewID int64, version int, editorID int64) error {
+	query := `
+		INSERT INTO review_revisions (review_id, version, author, rating, comment, status, created_at, replaced_by)
+		SELECT review_id, version, author, rating, comment, status, COALESCE(edited_at, created_at), NULLIF($3, 0)
+		FROM reviews
+		WHERE review_id = $1 AND version = $2 AND deleted_at IS NULL
+		FOR UPDATE
+	`
+	result, err := tx.ExecContext(ctx, query, reviewID, version, editorID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+	return nil
+}
+
+// GetReviewRevisions lists the earlier versions of a review, newest first unless filters
+// asks for another order.
+func (c ReviewModel) GetReviewRevisions(reviewID int64, filters Filters) ([]*ReviewRevision, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) OVER(), review_id, version, author, rating, comment, status, created_at, replaced_at, COALESCE(replaced_by, 0)
+		FROM review_revisions
+		WHERE review_id = $1
+		ORDER BY %s
+		LIMIT $2 OFFSET $3`, filters.orderBy("version", false))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := c.DB.QueryContext(ctx, query, reviewID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	revisions := []*ReviewRevision{}
+	for rows.Next() {
+		var revision ReviewRevision
+		err := rows.Scan(
+			&totalRecords,
+			&revision.ReviewID,
+			&revision.Version,
+			&revision.Author,
+			&revision.Rating,
+			&revision.Comment,
+			&revision.Status,
+			&revision.CreatedAt,
+			&revision.ReplacedAt,
+			&revision.ReplacedBy,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		revisions = append(revisions, &revision)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetaData(totalRecords, filters.Page, filters.PageSize)
+	return revisions, metadata, nil
+}
+
+// GetReviewRevision retrieves one earlier version of a review.
+// Returns ErrRecordNotFound if that version was never replaced by an edit.
+func (c ReviewModel) GetReviewRevision(reviewID int64, version int) (*ReviewRevision, error) {
+	query := `
+		SELECT review_id, version, author, rating, comment, status, created_at, replaced_at, COALESCE(replaced_by, 0)
+		FROM review_revisions
+		WHERE review_id = $1 AND version = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var revision ReviewRevision
+	err := c.DB.QueryRowContext(ctx, query, reviewID, version).Scan(
+		&revision.ReviewID,
+		&revision.Version,
+		&revision.Author,
+		&revision.Rating,
+		&revision.Comment,
+		&revision.Status,
+		&revision.CreatedAt,
+		&revision.ReplacedAt,
+		&revision.ReplacedBy,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &revision, nil
+}
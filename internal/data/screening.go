@@ -0,0 +1,217 @@
+// Filename: internal/data/screening.go
+package data
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ScreeningFinding is one problem a screener found with a review.
+type ScreeningFinding struct {
+	Rule   string `json:"rule"`   // Name of the rule that raised the finding.
+	Score  int    `json:"score"`  // How much the finding counts against the review.
+	Detail string `json:"detail"` // Human readable description, shown to moderators.
+}
+
+// ScreeningResult is the outcome of screening a review. The higher the score, the more
+// likely the review is spam or abuse.
+type ScreeningResult struct {
+	Score    int
+	Findings []ScreeningFinding
+}
+
+// Add records a finding and adds its score to the total.
+func (r *ScreeningResult) Add(finding ScreeningFinding) {
+	r.Score += finding.Score
+	r.Findings = append(r.Findings, finding)
+}
+
+// Reason summarises the findings for the review's moderation reason.
+func (r ScreeningResult) Reason() string {
+	details := make([]string, len(r.Findings))
+	for i, finding := range r.Findings {
+		details[i] = finding.Detail
+	}
+	return strings.Join(details, "; ")
+}
+
+// ReviewScreener checks a review's content before it is published. Screeners can be
+// combined with ScreenerChain, so new rules only need to implement this interface.
+type ReviewScreener interface {
+	Screen(review *Review) (ScreeningResult, error)
+}
+
+// ScreenerChain runs several screeners and adds up their findings.
+type ScreenerChain []ReviewScreener
+
+// Screen runs every screener in the chain against the review.
+func (c ScreenerChain) Screen(review *Review) (ScreeningResult, error) {
+	var total ScreeningResult
+	for _, screener := range c {
+		result, err := screener.Screen(review)
+		if err != nil {
+			return ScreeningResult{}, err
+		}
+		for _, finding := range result.Findings {
+			total.Add(finding)
+		}
+	}
+	return total, nil
+}
+
+// ScreeningPolicy turns a screening score into a moderation status: reviews scoring below
+// ApproveBelow are published straight away, reviews scoring RejectAt or more are rejected,
+// and everything in between waits in the moderation queue.
+type ScreeningPolicy struct {
+	ApproveBelow int
+	RejectAt     int
+}
+
+// Status returns the moderation status for a screening result.
+func (p ScreeningPolicy) Status(result ScreeningResult) string {
+	switch {
+	case result.Score >= p.RejectAt:
+		return ReviewStatusRejected
+	case result.Score < p.ApproveBelow:
+		return ReviewStatusApproved
+	default:
+		return ReviewStatusPending
+	}
+}
+
+// StatusAfterEdit returns the moderation status for a review that was at status current
+// and has been edited by its author. Screening may only approve a review that was pending
+// or approved already; one a moderator rejected or hid goes back to the queue at most.
+func (p ScreeningPolicy) StatusAfterEdit(result ScreeningResult, current string) string {
+	status := p.Status(result)
+	if status == ReviewStatusApproved && (current == ReviewStatusRejected || current == ReviewStatusHidden) {
+		return ReviewStatusPending
+	}
+	return status
+}
+
+// BannedWordsRule flags reviews containing any of a list of words. Matching ignores
+// case and only matches whole words, so "class" is not caught by "ass".
+type BannedWordsRule struct {
+	Words []string // Lower case words to look for.
+	Score int      // Score for each distinct banned word found.
+}
+
+// Screen looks for banned words in the review's comment.
+func (b BannedWordsRule) Screen(review *Review) (ScreeningResult, error) {
+	var result ScreeningResult
+	if len(b.Words) == 0 {
+		return result, nil
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(review.Comment), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	present := make(map[string]bool, len(words))
+	for _, word := range words {
+		present[word] = true
+	}
+
+	for _, banned := range b.Words {
+		if present[banned] {
+			result.Add(ScreeningFinding{Rule: "banned_words", Score: b.Score, Detail: fmt.Sprintf("contains the banned word %q", banned)})
+		}
+	}
+	return result, nil
+}
+
+// linkRX matches the start of a web link.
+var linkRX = regexp.MustCompile(`(?i)\b(https?://|www\.)`)
+
+// LinksRule flags reviews with more links than an honest review needs.
+type LinksRule struct {
+	MaxLinks int // Number of links allowed before the review is flagged.
+	Score    int
+}
+
+// Screen counts the links in the review's comment.
+func (l LinksRule) Screen(review *Review) (ScreeningResult, error) {
+	var result ScreeningResult
+	links := len(linkRX.FindAllStringIndex(review.Comment, -1))
+	if links > l.MaxLinks {
+		result.Add(ScreeningFinding{Rule: "links", Score: l.Score, Detail: fmt.Sprintf("contains %d links, at most %d are allowed", links, l.MaxLinks)})
+	}
+	return result, nil
+}
+
+// RepeatedCharactersRule flags reviews with long runs of one character, such as
+// "!!!!!!!!" or "sooooooo", which are typical of spam and shouting.
+type RepeatedCharactersRule struct {
+	MaxRun int // Longest run of a single character allowed.
+	Score  int
+}
+
+// Screen looks for the longest run of a repeated character in the review's comment.
+func (c RepeatedCharactersRule) Screen(review *Review) (ScreeningResult, error) {
+	var result ScreeningResult
+
+	var previous rune
+	run, longest := 0, 0
+	for _, r := range review.Comment {
+		if r == previous {
+			run++
+		} else {
+			previous, run = r, 1
+		}
+		// runs of spaces are only formatting
+		if run > longest && !unicode.IsSpace(r) {
+			longest = run
+		}
+	}
+
+	if longest > c.MaxRun {
+		result.Add(ScreeningFinding{Rule: "repeated_characters", Score: c.Score, Detail: fmt.Sprintf("repeats a character %d times in a row", longest)})
+	}
+	return result, nil
+}
+
+// DuplicateTextRule flags reviews whose text the same author has already posted on
+// another review, which is how copy-and-paste spam campaigns look.
+type DuplicateTextRule struct {
+	Reviews ReviewModel
+	Score   int
+}
+
+// Screen checks the author's other reviews for the same text.
+func (d DuplicateTextRule) Screen(review *Review) (ScreeningResult, error) {
+	var result ScreeningResult
+	if review.UserID == 0 {
+		return result, nil // Nothing to compare against without an author
+	}
+
+	duplicate, err := d.Reviews.AuthorHasDuplicateText(review.UserID, review.ReviewID, review.Comment)
+	if err != nil {
+		return ScreeningResult{}, err
+	}
+	if duplicate {
+		result.Add(ScreeningFinding{Rule: "duplicate_text", Score: d.Score, Detail: "repeats the text of another review by the same author"})
+	}
+	return result, nil
+}
+
+// AuthorHasDuplicateText reports whether the user has written a review other than reviewID
+// with the same text, ignoring case and surrounding whitespace.
+func (c ReviewModel) AuthorHasDuplicateText(userID int64, reviewID int64, text string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM reviews
+			WHERE user_id = $1 AND review_id <> $2 AND deleted_at IS NULL AND lower(btrim(comment)) = lower(btrim($3))
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var exists bool
+	err := c.DB.QueryRowContext(ctx, query, userID, reviewID, text).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,64 @@
+// Filename: internal/data/stats.go
+package data
+
+import (
+	"context"
+	"time"
+)
+
+// ReviewStats summarises the approved reviews of a product.
+type ReviewStats struct {
+	ProductID          int64       `json:"product_id"`
+	Count              int64       `json:"count"`                      // Number of approved reviews
+	Mean               float64     `json:"mean"`                       // Average rating, 0 when there are no reviews
+	Median             float64     `json:"median"`                     // Middle rating, 0 when there are no reviews
+	Histogram          map[int]int `json:"histogram"`                  // Number of reviews for each star rating from 1 to 5
+	PercentRecommended float64     `json:"percent_recommended"`        // Share of reviews rating the product 4 stars or more
+	LatestReviewAt     *time.Time  `json:"latest_review_at,omitempty"` // When the newest review was written
+}
+
+// GetReviewStats computes the review statistics for a product from its approved reviews.
+func (c ReviewModel) GetReviewStats(productID int64) (*ReviewStats, error) {
+	query := `
+		SELECT COUNT(*),
+			COALESCE(ROUND(AVG(rating)::numeric, 2), 0),
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY rating), 0),
+			COUNT(*) FILTER (WHERE rating = 1),
+			COUNT(*) FILTER (WHERE rating = 2),
+			COUNT(*) FILTER (WHERE rating = 3),
+			COUNT(*) FILTER (WHERE rating = 4),
+			COUNT(*) FILTER (WHERE rating = 5),
+			COALESCE(ROUND(100.0 * COUNT(*) FILTER (WHERE rating >= 4) / NULLIF(COUNT(*), 0), 1), 0),
+			MAX(created_at)
+		FROM reviews
+		WHERE product_id = $1 AND status = 'approved' AND deleted_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stats := ReviewStats{ProductID: productID}
+	var stars [5]int
+	err := c.DB.QueryRowContext(ctx, query, productID).Scan(
+		&stats.Count,
+		&stats.Mean,
+		&stats.Median,
+		&stars[0],
+		&stars[1],
+		&stars[2],
+		&stars[3],
+		&stars[4],
+		&stats.PercentRecommended,
+		&stats.LatestReviewAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Histogram = make(map[int]int, len(stars))
+	for i, count := range stars {
+		stats.Histogram[i+1] = count
+	}
+
+	return &stats, nil
+}
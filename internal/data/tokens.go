@@ -0,0 +1,102 @@
+// Filename: internal/data/tokens.go
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"time"
+
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// The scopes a token can be issued for.
+const (
+	ScopeActivation     = "activation"
+	ScopeAuthentication = "authentication"
+)
+
+// Token holds a stateful token. Only the hash is stored in the database;
+// the plaintext is handed to the client once and never kept.
+type Token struct {
+	Plaintext string    `json:"token"`  // Plaintext token sent to the client.
+	Hash      []byte    `json:"-"`      // SHA-256 hash of the plaintext token.
+	UserID    int64     `json:"-"`      // User the token belongs to.
+	Expiry    time.Time `json:"expiry"` // Time after which the token is no longer valid.
+	Scope     string    `json:"-"`      // What the token may be used for.
+}
+
+// TokenModel provides methods for interacting with the tokens database table.
+type TokenModel struct {
+	DB *sql.DB // Database connection pool.
+}
+
+// generateToken creates a new random token for the user along with its hash.
+func generateToken(userID int64, ttl time.Duration, scope string) (*Token, error) {
+	token := &Token{
+		UserID: userID,
+		Expiry: time.Now().Add(ttl),
+		Scope:  scope,
+	}
+
+	// 16 random bytes gives us 128 bits of entropy
+	randomBytes := make([]byte, 16)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Plaintext = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+	hash := sha256.Sum256([]byte(token.Plaintext))
+	token.Hash = hash[:]
+
+	return token, nil
+}
+
+// ValidateTokenPlaintext checks that a plaintext token has the expected shape.
+func ValidateTokenPlaintext(v *validator.Validator, tokenPlaintext string) {
+	v.Check(tokenPlaintext != "", "token", "must be provided")
+	v.Check(len(tokenPlaintext) == 26, "token", "must be 26 bytes long")
+}
+
+// NewToken generates a token for the user and stores it in the database.
+func (t TokenModel) NewToken(userID int64, ttl time.Duration, scope string) (*Token, error) {
+	token, err := generateToken(userID, ttl, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.InsertToken(token)
+	return token, err
+}
+
+// InsertToken adds a token to the database.
+func (t TokenModel) InsertToken(token *Token) error {
+	query := `
+		INSERT INTO tokens (hash, user_id, expiry, scope)
+		VALUES ($1, $2, $3, $4)
+	`
+	args := []any{token.Hash, token.UserID, token.Expiry, token.Scope}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := t.DB.ExecContext(ctx, query, args...)
+	return err
+}
+
+// DeleteAllTokensForUser removes every token of the given scope belonging to the user.
+func (t TokenModel) DeleteAllTokensForUser(scope string, userID int64) error {
+	query := `
+		DELETE FROM tokens
+		WHERE scope = $1 AND user_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := t.DB.ExecContext(ctx, query, scope, userID)
+	return err
+}
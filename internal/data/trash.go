@@ -0,0 +1,119 @@
+// Filename: internal/data/trash.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// The kinds of record that can be in the trash.
+const (
+	TrashTypeProduct = "product"
+	TrashTypeReview  = "review"
+)
+
+// TrashTypes lists every kind of record that can be in the trash.
+var TrashTypes = []string{TrashTypeProduct, TrashTypeReview}
+
+// TrashItem is a deleted product or review waiting to be restored or purged.
+type TrashItem struct {
+	Type      string    `json:"type"`       // One of TrashTypes
+	ID        int64     `json:"id"`         // ID of the product or review
+	Title     string    `json:"title"`      // Product name, or the start of the review text
+	DeletedAt time.Time `json:"deleted_at"` // When it was moved to the trash
+	PurgeAt   time.Time `json:"purge_at"`   // When it will be deleted for good
+}
+
+// TrashModel wraps the database connection pool for listing and purging the trash.
+type TrashModel struct {
+	DB        *sql.DB
+	Retention time.Duration // How long deleted records are kept before they are purged.
+}
+
+// ValidateTrashType checks the kind of record asked for, an empty type means both.
+func ValidateTrashType(v *validator.Validator, kind string) {
+	if kind != "" {
+		v.Check(validator.PermittedValue(kind, TrashTypes...), "type", "must be either product or review")
+	}
+}
+
+// GetTrash lists the products and reviews in the trash, most recently deleted first unless
+// filters asks for another order. kind limits the list to products or reviews.
+func (m TrashModel) GetTrash(kind string, filters Filters) ([]*TrashItem, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) OVER(), type, id, title, deleted_at
+		FROM (
+			SELECT 'product' AS type, product_id AS id, name AS title, deleted_at
+			FROM products
+			WHERE deleted_at IS NOT NULL
+			UNION ALL
+			SELECT 'review', review_id, COALESCE(author || ': ', '') || LEFT(comment, 50), deleted_at -- Legacy reviews have no author
+			FROM reviews
+			WHERE deleted_at IS NOT NULL
+		) AS trash
+		WHERE (type = $1 OR $1 = '')
+		ORDER BY %s
+		LIMIT $2 OFFSET $3`, filters.orderBy("type, id", false))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, kind, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	items := []*TrashItem{}
+	for rows.Next() {
+		var item TrashItem
+		err := rows.Scan(&totalRecords, &item.Type, &item.ID, &item.Title, &item.DeletedAt)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		item.PurgeAt = item.DeletedAt.Add(m.Retention)
+		items = append(items, &item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetaData(totalRecords, filters.Page, filters.PageSize)
+	return items, metadata, nil
+}
+
+// Purge deletes for good the products and reviews that have been in the trash for longer
+// than the retention period, and reports how many of each were removed. Purging a product
+// also removes all of its reviews.
+func (m TrashModel) Purge() (products int64, reviews int64, err error) {
+	// A big trash can take a while to empty
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cutoff := time.Now().Add(-m.Retention)
+
+	result, err := m.DB.ExecContext(ctx, `DELETE FROM reviews WHERE deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, 0, err
+	}
+	reviews, err = result.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	result, err = m.DB.ExecContext(ctx, `DELETE FROM products WHERE deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, reviews, err
+	}
+	products, err = result.RowsAffected()
+	if err != nil {
+		return 0, reviews, err
+	}
+
+	return products, reviews, nil
+}
@@ -0,0 +1,226 @@
+// Filename: internal/data/users.go
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Duane-Arzu/test2/internal/validator"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User represents a registered account that can call the API.
+type User struct {
+	ID        int64     `json:"id"`         // Unique identifier for the user.
+	CreatedAt time.Time `json:"created_at"` // Timestamp for when the user registered.
+	Name      string    `json:"name"`       // Display name of the user.
+	Email     string    `json:"email"`      // Email address, unique per user.
+	Password  password  `json:"-"`          // Plaintext and hashed password (never exposed in JSON).
+	Activated bool      `json:"activated"`  // Whether the user has activated their account.
+	Version   int       `json:"version"`    // Version for optimistic locking during updates.
+}
+
+// AnonymousUser represents a client that did not supply an authentication token.
+var AnonymousUser = &User{}
+
+// IsAnonymous reports whether the user is the AnonymousUser.
+func (u *User) IsAnonymous() bool {
+	return u == AnonymousUser
+}
+
+// password holds the plaintext password supplied by the client (if any)
+// together with its bcrypt hash, which is what gets stored in the database.
+type password struct {
+	plaintext *string
+	hash      []byte
+}
+
+// Set hashes the plaintext password and stores both versions in the struct.
+func (p *password) Set(plaintextPassword string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plaintextPassword), 12)
+	if err != nil {
+		return err
+	}
+
+	p.plaintext = &plaintextPassword
+	p.hash = hash
+
+	return nil
+}
+
+// Matches checks whether the plaintext password matches the stored hash.
+func (p *password) Matches(plaintextPassword string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword(p.hash, []byte(plaintextPassword))
+	if err != nil {
+		switch {
+		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// UserModel provides methods for interacting with the users database table.
+type UserModel struct {
+	DB *sql.DB // Database connection pool.
+}
+
+// ValidateEmail checks that an email address is present and well formed.
+func ValidateEmail(v *validator.Validator, email string) {
+	v.Check(email != "", "email", "must be provided")
+	v.Check(validator.Matches(email, validator.EmailRX), "email", "must be a valid email address")
+}
+
+// ValidatePasswordPlaintext checks the length of a plaintext password.
+func ValidatePasswordPlaintext(v *validator.Validator, password string) {
+	v.Check(password != "", "password", "must be provided")
+	v.Check(len(password) >= 8, "password", "must be at least 8 bytes long")
+	v.Check(len(password) <= 72, "password", "must not be more than 72 bytes long") // bcrypt ignores anything past 72 bytes.
+}
+
+// ValidateUser checks if the fields in the User struct adhere to specified validation rules.
+func ValidateUser(v *validator.Validator, user *User) {
+	v.Check(user.Name != "", "name", "must be provided")                         // Ensure a display name is provided.
+	v.Check(len(user.Name) <= 25, "name", "must not be more than 25 bytes long") // Keep names short enough to show as a review author.
+	ValidateEmail(v, user.Email)                                                 // Email must be present and well formed.
+	if user.Password.plaintext != nil {                                          // Only check the plaintext when one was supplied.
+		ValidatePasswordPlaintext(v, *user.Password.plaintext)
+	}
+	// A missing hash means we forgot to call Set(), which is a bug rather than bad input.
+	if user.Password.hash == nil {
+		panic("missing password hash for user")
+	}
+}
+
+// InsertUser adds a new user to the database, returning the user's ID, creation time, and version.
+func (u UserModel) InsertUser(user *User) error {
+	query := `
+		INSERT INTO users (name, email, password_hash, activated)
+		VALUES ($1, $2, $3, $4)
+		RETURNING user_id, created_at, version
+	`
+	args := []any{user.Name, user.Email, user.Password.hash, user.Activated}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := u.DB.QueryRowContext(ctx, query, args...).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Version,
+	)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
+			return ErrDuplicateEmail
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetUserByEmail retrieves a user by their email address, returning ErrRecordNotFound if there is none.
+func (u UserModel) GetUserByEmail(email string) (*User, error) {
+	query := `
+		SELECT user_id, created_at, name, email, password_hash, activated, version
+		FROM users
+		WHERE email = $1
+	`
+
+	var user User
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := u.DB.QueryRowContext(ctx, query, email).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Name,
+		&user.Email,
+		&user.Password.hash,
+		&user.Activated,
+		&user.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// UpdateUser saves changes to a user. The update only succeeds if the version in the
+// database still matches the one we read, otherwise ErrEditConflict is returned.
+func (u UserModel) UpdateUser(user *User) error {
+	query := `
+		UPDATE users
+		SET name = $1, email = $2, password_hash = $3, activated = $4, version = version + 1
+		WHERE user_id = $5 AND version = $6
+		RETURNING version
+	`
+	args := []any{user.Name, user.Email, user.Password.hash, user.Activated, user.ID, user.Version}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := u.DB.QueryRowContext(ctx, query, args...).Scan(&user.Version)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
+			return ErrDuplicateEmail
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetUserForToken retrieves the user owning a valid, unexpired token of the given scope.
+func (u UserModel) GetUserForToken(tokenScope, tokenPlaintext string) (*User, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `
+		SELECT users.user_id, users.created_at, users.name, users.email, users.password_hash, users.activated, users.version
+		FROM users
+		INNER JOIN tokens
+		ON users.user_id = tokens.user_id
+		WHERE tokens.hash = $1
+		AND tokens.scope = $2
+		AND tokens.expiry > $3
+	`
+	args := []any{tokenHash[:], tokenScope, time.Now()}
+
+	var user User
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := u.DB.QueryRowContext(ctx, query, args...).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Name,
+		&user.Email,
+		&user.Password.hash,
+		&user.Activated,
+		&user.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,159 @@
+// Filename: internal/data/votes.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Duane-Arzu/test2/internal/validator"
+)
+
+// The values a review vote can take.
+const (
+	VoteUp   = "up"   // The review was helpful.
+	VoteDown = "down" // The review was unhelpful.
+)
+
+// ReviewVote is one user's helpful/unhelpful verdict on a review.
+type ReviewVote struct {
+	ReviewID       int64     `json:"review_id"`       // Review being voted on
+	UserID         int64     `json:"user_id"`         // User casting the vote
+	Vote           string    `json:"vote"`            // VoteUp or VoteDown
+	CreatedAt      time.Time `json:"-"`               // When the vote was first cast
+	UpdatedAt      time.Time `json:"-"`               // When the vote was last changed
+	HelpfulCount   int32     `json:"helpful_count"`   // Review's helpful count after the vote
+	UnhelpfulCount int32     `json:"unhelpful_count"` // Review's unhelpful count after the vote
+}
+
+// ReviewVoteModel wraps the database connection pool for managing review votes.
+type ReviewVoteModel struct {
+	DB *sql.DB
+}
+
+// ValidateReviewVote checks that the vote is one of the allowed values.
+func ValidateReviewVote(v *validator.Validator, vote *ReviewVote) {
+	v.Check(validator.PermittedValue(vote.Vote, VoteUp, VoteDown), "vote", "must be either up or down")
+}
+
+// CastVote records the user's vote on a review, replacing any vote they cast before,
+// and refreshes the review's counts in the same transaction. created reports whether
+// this was the user's first vote on the review.
+func (m ReviewVoteModel) CastVote(vote *ReviewVote) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // Has no effect once the transaction is committed
+
+	err = lockReview(ctx, tx, vote.ReviewID)
+	if err != nil {
+		return false, err
+	}
+
+	// xmax is 0 for a freshly inserted row and set when an existing vote was updated
+	query := `
+		INSERT INTO review_votes (review_id, user_id, vote)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (review_id, user_id) DO UPDATE
+		SET vote = EXCLUDED.vote, updated_at = NOW()
+		RETURNING created_at, updated_at, (xmax = 0)
+	`
+	var created bool
+	err = tx.QueryRowContext(ctx, query, vote.ReviewID, vote.UserID, vote.Vote).Scan(&vote.CreatedAt, &vote.UpdatedAt, &created)
+	if err != nil {
+		return false, err
+	}
+
+	err = recountVotes(ctx, tx, vote)
+	if err != nil {
+		return false, err
+	}
+
+	return created, tx.Commit()
+}
+
+// RetractVote removes the user's vote on a review and refreshes the review's counts
+// in the same transaction. It returns ErrRecordNotFound if the user had not voted.
+func (m ReviewVoteModel) RetractVote(vote *ReviewVote) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockReview(ctx, tx, vote.ReviewID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		DELETE FROM review_votes
+		WHERE review_id = $1 AND user_id = $2
+		RETURNING vote, created_at, updated_at
+	`
+	err = tx.QueryRowContext(ctx, query, vote.ReviewID, vote.UserID).Scan(&vote.Vote, &vote.CreatedAt, &vote.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	err = recountVotes(ctx, tx, vote)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockReview takes a row lock on the review so that concurrent votes on it are counted one
+// after the other. It returns ErrRecordNotFound if the review doesn't exist or is in the trash,
+// or if its product isn't published or is in the trash.
+func lockReview(ctx context.Context, tx *sql.Tx, reviewID int64) error {
+	query := `
+		SELECT review_id
+		FROM reviews
+		WHERE review_id = $1 AND deleted_at IS NULL
+		AND EXISTS (SELECT 1 FROM products WHERE products.product_id = reviews.product_id AND products.deleted_at IS NULL AND products.status = 'published')
+		FOR UPDATE OF reviews
+	`
+	var id int64
+	err := tx.QueryRowContext(ctx, query, reviewID).Scan(&id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// recountVotes sets the review's helpful and unhelpful counts from the votes table
+// and copies the new counts onto the vote.
+func recountVotes(ctx context.Context, tx *sql.Tx, vote *ReviewVote) error {
+	query := `
+		UPDATE reviews
+		SET helpful_count = counts.up, unhelpful_count = counts.down
+		FROM (
+			SELECT COUNT(*) FILTER (WHERE vote = 'up') AS up, COUNT(*) FILTER (WHERE vote = 'down') AS down
+			FROM review_votes
+			WHERE review_id = $1
+		) AS counts
+		WHERE review_id = $1
+		RETURNING helpful_count, unhelpful_count
+	`
+	return tx.QueryRowContext(ctx, query, vote.ReviewID).Scan(&vote.HelpfulCount, &vote.UnhelpfulCount)
+}
@@ -0,0 +1,61 @@
+// Filename: internal/mailer/mailer.go
+package mailer
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mailer sends a plain text email to a single recipient.
+type Mailer interface {
+	Send(recipient string, subject string, body string) error
+}
+
+// SMTPMailer sends email through an SMTP server, authenticating when a username is set.
+type SMTPMailer struct {
+	Host     string // SMTP server host name.
+	Port     int    // SMTP server port, usually 587 for STARTTLS.
+	Username string // Username to authenticate with, empty for none.
+	Password string // Password to authenticate with.
+	Sender   string // From address, e.g. "Products <no-reply@example.com>".
+}
+
+// Send delivers the email. It blocks until the server has accepted it, so callers
+// should run it in the background rather than while a client waits.
+func (m SMTPMailer) Send(recipient string, subject string, body string) error {
+	// header values must stay on one line, or a value could add headers of its own
+	clean := strings.NewReplacer("\r", "", "\n", "")
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		clean.Replace(m.Sender), clean.Replace(recipient), clean.Replace(subject), time.Now().Format(time.RFC1123Z),
+		strings.ReplaceAll(body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	from := m.Sender
+	if start, end := strings.LastIndex(from, "<"), strings.LastIndex(from, ">"); start >= 0 && end > start {
+		from = from[start+1 : end] // The envelope sender is the bare address.
+	}
+
+	address := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	return smtp.SendMail(address, auth, from, []string{recipient}, []byte(message))
+}
+
+// LogMailer writes email to the log instead of sending it. It is meant for development,
+// where there is no mail server but someone still needs to read the messages.
+type LogMailer struct {
+	Logger *slog.Logger
+}
+
+// Send logs the email.
+func (m LogMailer) Send(recipient string, subject string, body string) error {
+	m.Logger.Info("email not sent, no SMTP server configured", "to", recipient, "subject", subject, "body", body)
+	return nil
+}
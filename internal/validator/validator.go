@@ -2,9 +2,13 @@
 package validator
 
 import (
+	"regexp"
 	"slices"
 )
 
+// EmailRX is the pattern used to check that an email address is well formed
+var EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
+
 // new type named Validator
 type Validator struct {
 	Errors map[string]string
@@ -41,3 +45,8 @@ func (v *Validator) Check(acceptable bool, key string, message string) {
 func PermittedValue(value string, permittedValues ...string) bool {
 	return slices.Contains(permittedValues, value)
 }
+
+// Matches returns true if the value satisfies the regular expression
+func Matches(value string, rx *regexp.Regexp) bool {
+	return rx.MatchString(value)
+}
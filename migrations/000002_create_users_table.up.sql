@@ -0,0 +1,13 @@
+-- citext gives us case-insensitive email comparisons
+CREATE EXTENSION IF NOT EXISTS citext;
+
+-- Create a table to store registered API users
+CREATE TABLE IF NOT EXISTS users (
+    user_id bigserial PRIMARY KEY,           -- Unique ID for each user
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(), -- Date the user registered
+    name text NOT NULL,                      -- Display name of the user
+    email citext UNIQUE NOT NULL,            -- Email address, unique regardless of case
+    password_hash bytea NOT NULL,            -- bcrypt hash of the user's password
+    activated bool NOT NULL DEFAULT false,   -- Whether the account has been activated
+    version integer NOT NULL DEFAULT 1       -- Version for tracking changes
+);
@@ -0,0 +1,2 @@
+-- Drop the tokens table
+DROP TABLE IF EXISTS tokens;
@@ -0,0 +1,7 @@
+-- Create a table to store activation, authentication and password-reset tokens
+CREATE TABLE IF NOT EXISTS tokens (
+    hash bytea PRIMARY KEY,                  -- SHA-256 hash of the plaintext token
+    user_id bigint NOT NULL REFERENCES users(user_id) ON DELETE CASCADE, -- User the token was issued to
+    expiry timestamp(0) WITH TIME ZONE NOT NULL, -- Time after which the token is no longer valid
+    scope text NOT NULL                      -- What the token may be used for
+);
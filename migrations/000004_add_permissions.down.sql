@@ -0,0 +1,5 @@
+-- Drop the link table first as it references "permissions"
+DROP TABLE IF EXISTS users_permissions;
+
+-- Then drop the permissions table
+DROP TABLE IF EXISTS permissions;
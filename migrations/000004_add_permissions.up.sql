@@ -0,0 +1,18 @@
+-- Create a table to store the permission codes that can be granted to users
+CREATE TABLE IF NOT EXISTS permissions (
+    permission_id bigserial PRIMARY KEY,     -- Unique ID for each permission
+    code text NOT NULL UNIQUE                -- Permission code, e.g. products:write
+);
+
+-- Link users to the permissions they hold
+CREATE TABLE IF NOT EXISTS users_permissions (
+    user_id bigint NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    permission_id bigint NOT NULL REFERENCES permissions(permission_id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, permission_id)
+);
+
+-- Seed the permissions used by the API
+INSERT INTO permissions (code)
+VALUES
+    ('products:write'),                      -- Catalog admins: create, update and delete products
+    ('reviews:moderate');                    -- Moderators: manage any review
@@ -0,0 +1,3 @@
+-- Remove the index and the link between reviews and users
+DROP INDEX IF EXISTS reviews_user_id_idx;
+ALTER TABLE reviews DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,6 @@
+-- Link each review to the user who wrote it. Existing reviews predate user
+-- accounts so the column stays nullable, and a deleted user leaves their reviews behind
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS user_id bigint REFERENCES users(user_id) ON DELETE SET NULL;
+
+-- Speed up looking up the reviews written by a user
+CREATE INDEX IF NOT EXISTS reviews_user_id_idx ON reviews(user_id);
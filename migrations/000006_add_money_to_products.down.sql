@@ -0,0 +1,16 @@
+-- Put back any prices that only exist in the new columns before dropping them.
+-- price_amount is in minor units, so divide by 10 ^ the currency's ISO 4217 exponent
+UPDATE products
+SET price = CASE
+    WHEN currency IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW',
+                      'PYG', 'RWF', 'UGX', 'VND', 'VUV', 'XAF', 'XOF', 'XPF')
+        THEN price_amount::text
+    WHEN currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND')
+        THEN TO_CHAR(price_amount / 1000.0, 'FM999999999990.000')
+    ELSE TO_CHAR(price_amount / 100.0, 'FM999999999990.00')
+END
+WHERE price IS NULL AND price_amount IS NOT NULL;
+
+ALTER TABLE products ALTER COLUMN price SET NOT NULL;
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
+ALTER TABLE products DROP COLUMN IF EXISTS price_amount;
@@ -0,0 +1,13 @@
+-- Store prices as integer minor units (e.g. cents) plus an ISO 4217 currency code
+ALTER TABLE products ADD COLUMN IF NOT EXISTS price_amount bigint CHECK (price_amount >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS currency char(3) NOT NULL DEFAULT 'USD';
+
+-- Convert the old free-text prices that are plain amounts such as "12.99" or "$12.99".
+-- Anything else is left NULL and the API keeps reading it from the old price column
+UPDATE products
+SET price_amount = ROUND(regexp_replace(price, '[^0-9.]', '', 'g')::numeric * 100)
+WHERE price_amount IS NULL
+AND price ~ '^\s*\$?\s*[0-9][0-9,]*(\.[0-9]{1,2})?\s*$';
+
+-- The old column is kept (and still written) until every row has been converted
+ALTER TABLE products ALTER COLUMN price DROP NOT NULL;
@@ -0,0 +1,6 @@
+-- Drop the product list filter indexes
+DROP INDEX IF EXISTS products_created_at_idx;
+DROP INDEX IF EXISTS products_avg_rating_idx;
+DROP INDEX IF EXISTS products_currency_price_amount_idx;
+DROP INDEX IF EXISTS products_category_idx;
+DROP INDEX IF EXISTS products_name_idx;
@@ -0,0 +1,10 @@
+-- Full-text indexes for the name and category searches on the product list
+CREATE INDEX IF NOT EXISTS products_name_idx ON products USING GIN (to_tsvector('simple', name));
+CREATE INDEX IF NOT EXISTS products_category_idx ON products USING GIN (to_tsvector('simple', category));
+
+-- Price bounds always compare within one currency, so index both together
+CREATE INDEX IF NOT EXISTS products_currency_price_amount_idx ON products (currency, price_amount);
+
+-- Indexes for the rating and creation date filters
+CREATE INDEX IF NOT EXISTS products_avg_rating_idx ON products (avg_rating);
+CREATE INDEX IF NOT EXISTS products_created_at_idx ON products (created_at);
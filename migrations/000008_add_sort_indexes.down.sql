@@ -0,0 +1,5 @@
+-- Drop the review sort indexes
+DROP INDEX IF EXISTS reviews_created_at_idx;
+DROP INDEX IF EXISTS reviews_helpful_count_idx;
+DROP INDEX IF EXISTS reviews_rating_idx;
+DROP INDEX IF EXISTS reviews_product_id_idx;
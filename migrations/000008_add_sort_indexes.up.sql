@@ -0,0 +1,7 @@
+-- Index the foreign key used to count and list a product's reviews
+CREATE INDEX IF NOT EXISTS reviews_product_id_idx ON reviews (product_id);
+
+-- Indexes for the review sort keys
+CREATE INDEX IF NOT EXISTS reviews_rating_idx ON reviews (rating);
+CREATE INDEX IF NOT EXISTS reviews_helpful_count_idx ON reviews (helpful_count);
+CREATE INDEX IF NOT EXISTS reviews_created_at_idx ON reviews (created_at);
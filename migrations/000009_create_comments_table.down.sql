@@ -0,0 +1,2 @@
+-- Remove the comments table and its indexes
+DROP TABLE IF EXISTS comments;
@@ -0,0 +1,17 @@
+-- Comments left on a review. A comment with a parent_comment_id is a reply to that
+-- comment, so the comments on a review form a tree. Deleting a review or a comment
+-- takes its replies with it
+CREATE TABLE IF NOT EXISTS comments (
+    comment_id bigserial PRIMARY KEY,                                           -- Unique ID for each comment
+    review_id bigint NOT NULL REFERENCES reviews(review_id) ON DELETE CASCADE,  -- Review the comment belongs to
+    parent_comment_id bigint REFERENCES comments(comment_id) ON DELETE CASCADE, -- Comment being replied to, NULL for top-level comments
+    user_id bigint REFERENCES users(user_id) ON DELETE SET NULL,                -- User who wrote the comment
+    author text NOT NULL,                                                       -- Display name of the author
+    content text NOT NULL,                                                      -- Comment text
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),              -- Date comment was created
+    version integer NOT NULL DEFAULT 1                                          -- Version for tracking comment updates
+);
+
+-- Speed up loading the comments of a review and the replies to a comment
+CREATE INDEX IF NOT EXISTS comments_review_id_idx ON comments (review_id);
+CREATE INDEX IF NOT EXISTS comments_parent_comment_id_idx ON comments (parent_comment_id);
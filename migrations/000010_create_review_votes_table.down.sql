@@ -0,0 +1,5 @@
+-- Remove the votes table and the unhelpful count
+ALTER TABLE reviews ALTER COLUMN helpful_count DROP NOT NULL;
+ALTER TABLE reviews DROP COLUMN IF EXISTS unhelpful_count;
+ALTER TABLE reviews DROP COLUMN IF EXISTS legacy_helpful_count;
+DROP TABLE IF EXISTS review_votes;
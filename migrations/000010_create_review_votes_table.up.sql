@@ -0,0 +1,20 @@
+-- One row per user per review recording whether they found it helpful ('up')
+-- or unhelpful ('down'). The primary key stops a user voting twice
+CREATE TABLE IF NOT EXISTS review_votes (
+    review_id bigint NOT NULL REFERENCES reviews(review_id) ON DELETE CASCADE, -- Review being voted on
+    user_id bigint NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,       -- User casting the vote
+    vote text NOT NULL CHECK (vote IN ('up', 'down')),                         -- Helpful or unhelpful
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),             -- When the vote was first cast
+    updated_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),             -- When the vote was last changed
+    PRIMARY KEY (review_id, user_id)
+);
+
+-- Keep a count of unhelpful votes next to the helpful ones
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS unhelpful_count integer NOT NULL DEFAULT 0;
+
+-- The old helpful counts can't be traced back to voters, so both counts now start from the
+-- votes table (which is empty) and are kept in step with it from here on. The old totals
+-- are kept, read-only, in legacy_helpful_count rather than being thrown away
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS legacy_helpful_count integer NOT NULL DEFAULT 0;
+UPDATE reviews SET legacy_helpful_count = COALESCE(helpful_count, 0), helpful_count = 0, unhelpful_count = 0;
+ALTER TABLE reviews ALTER COLUMN helpful_count SET NOT NULL;
@@ -0,0 +1,13 @@
+-- Reviews now wait in a moderation queue before the public can see them.
+-- Reviews written before moderation existed were already public, so they start out approved
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'approved'
+    CHECK (status IN ('pending', 'approved', 'rejected', 'hidden'));
+ALTER TABLE reviews ALTER COLUMN status SET DEFAULT 'pending';
+
+-- Who last moderated the review, when, and why
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS moderated_by bigint REFERENCES users(user_id) ON DELETE SET NULL;
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS moderated_at timestamp(0) WITH TIME ZONE;
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS moderation_reason text NOT NULL DEFAULT '';
+
+-- Speed up listing the reviews in each state
+CREATE INDEX IF NOT EXISTS reviews_status_idx ON reviews (status);
@@ -0,0 +1,2 @@
+-- Remove the review reports table
+DROP TABLE IF EXISTS review_reports;
@@ -0,0 +1,10 @@
+-- Reports from users who think a review is spam, offensive or off-topic.
+-- The primary key allows one report per user per review
+CREATE TABLE IF NOT EXISTS review_reports (
+    review_id bigint NOT NULL REFERENCES reviews(review_id) ON DELETE CASCADE,   -- Review being reported
+    user_id bigint NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,         -- User making the report
+    reason text NOT NULL CHECK (reason IN ('spam', 'offensive', 'off_topic')),   -- What is wrong with the review
+    details text NOT NULL DEFAULT '',                                             -- Optional explanation from the user
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),               -- When the report was made
+    PRIMARY KEY (review_id, user_id)
+);
@@ -0,0 +1,28 @@
+-- Remove the running totals and their trigger
+DROP INDEX IF EXISTS products_review_count_idx;
+DROP TRIGGER IF EXISTS maintain_product_ratings ON reviews;
+DROP FUNCTION IF EXISTS maintain_product_ratings();
+DROP FUNCTION IF EXISTS adjust_product_rating(bigint, float8, integer);
+ALTER TABLE products DROP COLUMN IF EXISTS rating_sum;
+ALTER TABLE products DROP COLUMN IF EXISTS review_count;
+
+-- Put back the original trigger
+CREATE OR REPLACE FUNCTION automatic_average_rating()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE products
+    SET average_rating = (
+        SELECT ROUND(CAST(AVG(rating) AS NUMERIC), 2)
+        FROM reviews
+        WHERE reviews.product_id = NEW.product_id
+    )
+    WHERE product_id = NEW.product_id;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER update_product_rating
+AFTER INSERT OR UPDATE OR DELETE ON reviews
+FOR EACH ROW
+EXECUTE FUNCTION automatic_average_rating();
@@ -0,0 +1,60 @@
+-- The original trigger updated a column that doesn't exist (average_rating) and read NEW on
+-- DELETE, so product ratings were never kept up to date. Replace it with running totals
+DROP TRIGGER IF EXISTS update_product_rating ON reviews;
+DROP FUNCTION IF EXISTS automatic_average_rating();
+
+-- Running totals of the approved reviews of each product, from which avg_rating is derived
+ALTER TABLE products ADD COLUMN IF NOT EXISTS review_count bigint NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS rating_sum numeric NOT NULL DEFAULT 0;
+
+-- Add (sign = 1) or remove (sign = -1) one review's rating from a product's totals
+CREATE OR REPLACE FUNCTION adjust_product_rating(target_product bigint, review_rating float8, sign integer)
+RETURNS void AS $$
+BEGIN
+    UPDATE products
+    SET review_count = review_count + sign,
+        rating_sum = rating_sum + sign * review_rating::numeric,
+        avg_rating = COALESCE(ROUND((rating_sum + sign * review_rating::numeric) / NULLIF(review_count + sign, 0), 2), 0)
+    WHERE product_id = target_product;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Keep the totals in step with the reviews. Only approved reviews with a rating count, so a
+-- review is taken off its old product (OLD) and put on its new one (NEW) whenever its
+-- product, rating or status changes
+CREATE OR REPLACE FUNCTION maintain_product_ratings()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.status = 'approved' AND OLD.rating IS NOT NULL THEN
+        PERFORM adjust_product_rating(OLD.product_id, OLD.rating, -1);
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.status = 'approved' AND NEW.rating IS NOT NULL THEN
+        PERFORM adjust_product_rating(NEW.product_id, NEW.rating, 1);
+    END IF;
+
+    RETURN NULL; -- The result of an AFTER trigger is ignored
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER maintain_product_ratings
+AFTER INSERT OR DELETE OR UPDATE OF product_id, rating, status ON reviews
+FOR EACH ROW
+EXECUTE FUNCTION maintain_product_ratings();
+
+-- Fill in the totals for the reviews that already exist
+UPDATE products
+SET review_count = COALESCE(totals.review_count, 0),
+    rating_sum = COALESCE(totals.rating_sum, 0),
+    avg_rating = COALESCE(ROUND(totals.rating_sum / NULLIF(totals.review_count, 0), 2), 0)
+FROM products AS p
+LEFT JOIN (
+    SELECT product_id, COUNT(*) AS review_count, SUM(rating::numeric) AS rating_sum
+    FROM reviews
+    WHERE status = 'approved' AND rating IS NOT NULL
+    GROUP BY product_id
+) AS totals ON totals.product_id = p.product_id
+WHERE products.product_id = p.product_id;
+
+-- review_count is now a sort key
+CREATE INDEX IF NOT EXISTS products_review_count_idx ON products (review_count);
@@ -0,0 +1,2 @@
+-- Allow a user to review a product more than once again
+ALTER TABLE reviews DROP CONSTRAINT IF EXISTS reviews_user_id_product_id_key;
@@ -0,0 +1,14 @@
+-- Users who already reviewed a product more than once keep their newest review.
+-- Their older reviews are kept but no longer attributed to them, like the reviews of a deleted user
+UPDATE reviews
+SET user_id = NULL
+WHERE user_id IS NOT NULL
+AND review_id NOT IN (
+    SELECT DISTINCT ON (user_id, product_id) review_id
+    FROM reviews
+    WHERE user_id IS NOT NULL
+    ORDER BY user_id, product_id, created_at DESC, review_id DESC
+);
+
+-- One review per user per product. Reviews without a user (NULL) never conflict
+ALTER TABLE reviews ADD CONSTRAINT reviews_user_id_product_id_key UNIQUE (user_id, product_id);
@@ -0,0 +1,17 @@
+-- Earlier versions of a review. Before a review is edited its current content is copied
+-- here, so every version a review has been through can still be read
+CREATE TABLE IF NOT EXISTS review_revisions (
+    review_id bigint NOT NULL REFERENCES reviews(review_id) ON DELETE CASCADE, -- Review the version belongs to
+    version integer NOT NULL,                                                  -- The review's version number at the time
+    author text NOT NULL,                                                      -- Display name of the author at that version
+    rating integer NOT NULL,                                                   -- Rating at that version
+    comment text NOT NULL,                                                     -- Review text at that version
+    status text NOT NULL,                                                      -- Moderation status at that version
+    created_at timestamp(0) WITH TIME ZONE NOT NULL,                           -- When this content was written
+    replaced_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),            -- When an edit replaced it
+    replaced_by bigint REFERENCES users(user_id) ON DELETE SET NULL,           -- User who made the edit
+    PRIMARY KEY (review_id, version)
+);
+
+-- When the review's content was last edited, NULL for reviews that were never edited
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS edited_at timestamp(0) WITH TIME ZONE;
@@ -0,0 +1,2 @@
+-- Remove the audit log and its indexes
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,17 @@
+-- Every change made to an audited record: who made it, in which request, and the
+-- value of each changed field before and after
+CREATE TABLE IF NOT EXISTS audit_log (
+    audit_id bigserial PRIMARY KEY,                                    -- Unique ID for each entry
+    entity_type text NOT NULL,                                         -- Kind of record changed, e.g. product
+    entity_id bigint NOT NULL,                                         -- ID of the changed record, kept after the record is deleted
+    action text NOT NULL CHECK (action IN ('insert', 'update', 'delete')),
+    actor_id bigint REFERENCES users(user_id) ON DELETE SET NULL,     -- User who made the change
+    request_id text NOT NULL DEFAULT '',                               -- X-Request-ID of the request that made the change
+    changes jsonb NOT NULL,                                            -- {"field": {"before": ..., "after": ...}} for each changed field
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW()      -- When the change was made
+);
+
+-- The audit log is usually read for one record, one user or a period of time
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS audit_log_actor_id_idx ON audit_log (actor_id);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at);
@@ -0,0 +1,37 @@
+-- Without soft delete, whatever is in the trash is deleted for good
+DELETE FROM reviews WHERE deleted_at IS NOT NULL;
+DELETE FROM products WHERE deleted_at IS NOT NULL;
+
+DELETE FROM audit_log WHERE action = 'restore';
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check CHECK (action IN ('insert', 'update', 'delete'));
+
+-- Put back the trigger from before soft delete
+CREATE OR REPLACE FUNCTION maintain_product_ratings()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.status = 'approved' AND OLD.rating IS NOT NULL THEN
+        PERFORM adjust_product_rating(OLD.product_id, OLD.rating, -1);
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.status = 'approved' AND NEW.rating IS NOT NULL THEN
+        PERFORM adjust_product_rating(NEW.product_id, NEW.rating, 1);
+    END IF;
+
+    RETURN NULL; -- The result of an AFTER trigger is ignored
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS maintain_product_ratings ON reviews;
+CREATE TRIGGER maintain_product_ratings
+AFTER INSERT OR DELETE OR UPDATE OF product_id, rating, status ON reviews
+FOR EACH ROW
+EXECUTE FUNCTION maintain_product_ratings();
+
+DROP INDEX IF EXISTS reviews_user_id_product_id_key;
+ALTER TABLE reviews ADD CONSTRAINT reviews_user_id_product_id_key UNIQUE (user_id, product_id);
+
+DROP INDEX IF EXISTS reviews_deleted_at_idx;
+DROP INDEX IF EXISTS products_deleted_at_idx;
+ALTER TABLE reviews DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
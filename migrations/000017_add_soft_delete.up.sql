@@ -0,0 +1,38 @@
+-- Deleting a product or review now moves it to the trash by setting deleted_at. It can be
+-- restored until the retention period runs out and it is purged for good
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at timestamp(0) WITH TIME ZONE;
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS deleted_at timestamp(0) WITH TIME ZONE;
+
+-- The purge looks for rows that have been in the trash long enough
+CREATE INDEX IF NOT EXISTS products_deleted_at_idx ON products (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS reviews_deleted_at_idx ON reviews (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- A review in the trash doesn't stop its author from writing a new one for the product
+ALTER TABLE reviews DROP CONSTRAINT IF EXISTS reviews_user_id_product_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS reviews_user_id_product_id_key ON reviews (user_id, product_id) WHERE deleted_at IS NULL;
+
+-- Reviews in the trash no longer count towards their product's rating
+CREATE OR REPLACE FUNCTION maintain_product_ratings()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.status = 'approved' AND OLD.rating IS NOT NULL AND OLD.deleted_at IS NULL THEN
+        PERFORM adjust_product_rating(OLD.product_id, OLD.rating, -1);
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.status = 'approved' AND NEW.rating IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        PERFORM adjust_product_rating(NEW.product_id, NEW.rating, 1);
+    END IF;
+
+    RETURN NULL; -- The result of an AFTER trigger is ignored
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS maintain_product_ratings ON reviews;
+CREATE TRIGGER maintain_product_ratings
+AFTER INSERT OR DELETE OR UPDATE OF product_id, rating, status, deleted_at ON reviews
+FOR EACH ROW
+EXECUTE FUNCTION maintain_product_ratings();
+
+-- Restoring a product is recorded in the audit log too
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check CHECK (action IN ('insert', 'update', 'delete', 'restore'));
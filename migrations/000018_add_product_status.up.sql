@@ -0,0 +1,11 @@
+-- Products are written as drafts and only shown to the public once published. A draft with
+-- a publish_at time is published by the scheduler when that time arrives
+ALTER TABLE products ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'published', 'archived'));
+ALTER TABLE products ADD COLUMN IF NOT EXISTS publish_at timestamp(0) WITH TIME ZONE;
+
+-- Products that already exist were live, new ones start as drafts
+ALTER TABLE products ALTER COLUMN status SET DEFAULT 'draft';
+
+-- The scheduler looks for drafts whose publish time has come
+CREATE INDEX IF NOT EXISTS products_publish_at_idx ON products (publish_at) WHERE status = 'draft' AND publish_at IS NOT NULL;
@@ -0,0 +1,11 @@
+-- Writing reviews and comments is now a permission, granted to every user when they register
+INSERT INTO permissions (code)
+VALUES ('reviews:write')
+ON CONFLICT (code) DO NOTHING;
+
+-- Users who signed up before the permission existed could already write, so they keep that
+INSERT INTO users_permissions (user_id, permission_id)
+SELECT users.user_id, permissions.permission_id
+FROM users, permissions
+WHERE permissions.code = 'reviews:write'
+ON CONFLICT DO NOTHING;